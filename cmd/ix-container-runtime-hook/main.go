@@ -0,0 +1,52 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package main
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/hook"
+)
+
+// The ix-container-runtime-hook is an OCI hook that applies the modifications of
+// the ix-container-runtime to a container without replacing the engine's runtime.
+// The container state is read from stdin as defined by the OCI runtime spec.
+func main() {
+	err := run()
+	if err != nil {
+		log.Errorf("Error running %v: %v", os.Args, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	state, err := hook.ReadState(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	return hook.Run(cfg, state)
+}
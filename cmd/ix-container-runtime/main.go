@@ -26,11 +26,13 @@ import (
 )
 
 func main() {
-	r := runtime.New()
+	r := runtime.New(
+		runtime.WithModeOverride(runtime.ModeFromExecutable(os.Args[0])),
+	)
 
 	err := r.Run(os.Args)
 	if err != nil {
-		log.Printf("err:%v\n", err)
-		os.Exit(1)
+		log.Errorf("Error running %v: %v", os.Args, err)
+		os.Exit(runtime.ExitCode(err))
 	}
 }
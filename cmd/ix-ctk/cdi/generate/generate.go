@@ -40,6 +40,8 @@ type options struct {
 	deviceNameStrategies cli.StringSlice
 	vendor               string
 	class                string
+	driverLibraries      bool
+	ctkPath              string
 }
 
 // NewCommand constructs a generate-cdi command with the specified logger
@@ -87,6 +89,16 @@ func (m command) build() *cli.Command {
 			Value:       "gpu",
 			Destination: &opts.class,
 		},
+		&cli.BoolFlag{
+			Name:        "driver-libraries",
+			Usage:       "Include the host driver libraries in the container edits shared by all devices. The libraries are mounted into " + ixcdi.DriverLibraryDir + " and added to the linker cache of the container.",
+			Destination: &opts.driverLibraries,
+		},
+		&cli.StringFlag{
+			Name:        "ix-ctk-path",
+			Usage:       "Specify the path of ix-ctk used in the generated hooks. If this is '' the ctkpath from the config is used",
+			Destination: &opts.ctkPath,
+		},
 	}
 
 	return &c
@@ -127,10 +139,21 @@ func (m command) generateSpec(opts *options, cfg *config.Config) (spec.Interface
 		deviceNamers = append(deviceNamers, deviceNamer)
 	}
 
-	cdilib, err := ixcdi.New(
+	ctkPath := opts.ctkPath
+	if ctkPath == "" {
+		ctkPath = cfg.CTKPath
+	}
+
+	libOptions := []ixcdi.Option{
 		ixcdi.WithDeviceNamers(deviceNamers...),
 		ixcdi.WithLibraryPath(cfg.LibraryPath),
-	)
+		ixcdi.WithIXCTKPath(ctkPath),
+	}
+	if opts.driverLibraries {
+		libOptions = append(libOptions, ixcdi.WithDriverLibraries(ixcdi.DefaultDriverLibraries...))
+	}
+
+	cdilib, err := ixcdi.New(libOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CDI library: %v", err)
 	}
@@ -140,10 +163,16 @@ func (m command) generateSpec(opts *options, cfg *config.Config) (spec.Interface
 		return nil, fmt.Errorf("failed to create device CDI specs: %v", err)
 	}
 
+	commonEdits, err := cdilib.GetCommonEdits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create edits common for entities: %v", err)
+	}
+
 	return spec.New(
 		spec.WithVendor(opts.vendor),
 		spec.WithClass(opts.class),
 		spec.WithDeviceSpecs(deviceSpecs),
+		spec.WithEdits(*commonEdits.ContainerEdits),
 		spec.WithPermissions(0644),
 		spec.WithMergedDeviceOptions(
 			transform.WithName(allDeviceName),
@@ -0,0 +1,55 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"github.com/urfave/cli/v2"
+
+	defaultsubcommand "gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config/default"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config/get"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config/set"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config/show"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config/validate"
+)
+
+type command struct {
+}
+
+// NewCommand constructs a config command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	// Create the 'config' command
+	config := cli.Command{
+		Name:  "config",
+		Usage: "Interact with the ix-container-runtime config",
+	}
+
+	config.Subcommands = []*cli.Command{
+		show.NewCommand(),
+		get.NewCommand(),
+		set.NewCommand(),
+		defaultsubcommand.NewCommand(),
+		validate.NewCommand(),
+	}
+
+	return &config
+}
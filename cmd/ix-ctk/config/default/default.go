@@ -0,0 +1,75 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package defaultsubcommand
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+)
+
+type command struct{}
+
+type options struct {
+	output string
+}
+
+// NewCommand constructs a default command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	c := cli.Command{
+		Name:  "default",
+		Usage: "Print the default config",
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       "Specify the file to write the default config to. If this is '' the config is output to STDOUT",
+			Destination: &opts.output,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(opts *options) error {
+	data, err := yaml.Marshal(config.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+
+	if opts.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(opts.output, data, 0644)
+}
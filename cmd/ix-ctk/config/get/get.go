@@ -0,0 +1,95 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package get
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+)
+
+type command struct{}
+
+type options struct {
+	config string
+}
+
+// NewCommand constructs a get command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	get := cli.Command{
+		Name:      "get",
+		Usage:     "Print the effective value of a config setting, e.g. loglevel or cdi.specdirs",
+		ArgsUsage: "KEY",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("exactly one key must be specified")
+			}
+			return m.run(&opts, c.Args().First())
+		},
+	}
+
+	get.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "the path of the runtime config file",
+			Value:       config.ConfigFilePath,
+			EnvVars:     []string{config.ConfigFilePathEnvvar},
+			Destination: &opts.config,
+		},
+	}
+
+	return &get
+}
+
+func (m command) run(opts *options, key string) error {
+	cfg, err := config.LoadConfigFromFile(opts.config)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	value, err := cfg.Get(key)
+	if err != nil {
+		return err
+	}
+
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value: %v", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case nil:
+		fmt.Println()
+	default:
+		fmt.Println(value)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package set
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+)
+
+type command struct{}
+
+type options struct {
+	config string
+	dryRun bool
+}
+
+// NewCommand constructs a set command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	set := cli.Command{
+		Name:      "set",
+		Usage:     "Set a setting in the config file. Lists are comma-separated and maps are comma-separated key=value pairs",
+		ArgsUsage: "KEY VALUE",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return fmt.Errorf("a key and a value must be specified")
+			}
+			return m.run(&opts, c.Args().Get(0), c.Args().Get(1))
+		},
+	}
+
+	set.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "the path of the runtime config file",
+			Value:       config.ConfigFilePath,
+			EnvVars:     []string{config.ConfigFilePathEnvvar},
+			Destination: &opts.config,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "validate the setting but don't write changes to disk",
+			Destination: &opts.dryRun,
+		},
+	}
+
+	return &set
+}
+
+func (m command) run(opts *options, key string, value string) error {
+	f, err := config.LoadFile(opts.config)
+	if err != nil {
+		return err
+	}
+
+	err = f.Set(key, value)
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		return nil
+	}
+	err = f.Save()
+	if err != nil {
+		return err
+	}
+	log.Printf("Set %v in %v\n", key, f.Path())
+	return nil
+}
@@ -0,0 +1,78 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package show
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+)
+
+type command struct{}
+
+type options struct {
+	config string
+}
+
+// NewCommand constructs a show command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	show := cli.Command{
+		Name:  "show",
+		Usage: "Show the effective config including defaults, drop-in files and environment overrides",
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	show.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "the path of the runtime config file",
+			Value:       config.ConfigFilePath,
+			EnvVars:     []string{config.ConfigFilePathEnvvar},
+			Destination: &opts.config,
+		},
+	}
+
+	return &show
+}
+
+func (m command) run(opts *options) error {
+	cfg, err := config.LoadConfigFromFile(opts.config)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,102 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package validate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+)
+
+type command struct{}
+
+type options struct {
+	config string
+}
+
+// NewCommand constructs a validate command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	validate := cli.Command{
+		Name:  "validate",
+		Usage: "Check the config files for unknown keys, invalid values and inaccessible paths",
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	validate.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "the path of the runtime config file",
+			Value:       config.ConfigFilePath,
+			EnvVars:     []string{config.ConfigFilePathEnvvar},
+			Destination: &opts.config,
+		},
+	}
+
+	return &validate
+}
+
+func (m command) run(opts *options) error {
+	var problems []string
+
+	dropIns, err := filepath.Glob(filepath.Join(filepath.Dir(opts.config), config.ConfigDropInDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(dropIns)
+	for _, path := range append([]string{opts.config}, dropIns...) {
+		f, err := config.LoadFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		for _, key := range f.UnknownKeys() {
+			problems = append(problems, fmt.Sprintf("%v: unknown key %q", path, key))
+		}
+	}
+
+	cfg, err := config.LoadConfigFromFile(opts.config)
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		for _, err := range cfg.Validate() {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("Config is valid")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	return fmt.Errorf("found %d problem(s) in config", len(problems))
+}
@@ -0,0 +1,49 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package containers
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/containers/inspect"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/containers/list"
+)
+
+type command struct {
+}
+
+// NewCommand constructs a containers command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	// Create the 'containers' command
+	containers := cli.Command{
+		Name:  "containers",
+		Usage: "Query the resources the ix-container-runtime injected into containers",
+	}
+
+	containers.Subcommands = []*cli.Command{
+		list.NewCommand(),
+		inspect.NewCommand(),
+	}
+
+	return &containers
+}
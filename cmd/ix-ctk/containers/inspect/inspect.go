@@ -0,0 +1,93 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+type command struct{}
+
+type options struct {
+	stateDir string
+}
+
+// NewCommand constructs an inspect command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	inspect := cli.Command{
+		Name:      "inspect",
+		Usage:     "Show the record of the resources injected into a container",
+		ArgsUsage: "CONTAINER_ID",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("exactly one container ID must be specified")
+			}
+			return m.run(&opts, c.Args().First())
+		},
+	}
+
+	inspect.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "state-dir",
+			Usage:       "the directory of the container records. Defaults to the statedir from the runtime config",
+			Destination: &opts.stateDir,
+		},
+	}
+
+	return &inspect
+}
+
+func (m command) run(opts *options, id string) error {
+	stateDir := opts.stateDir
+	if stateDir == "" {
+		cfg, err := config.LoadConfigFromFile(config.GetConfigFilePath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		stateDir = cfg.StateDir
+	}
+
+	c, err := state.New(stateDir).Get(id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("no record for container %v", id)
+	}
+	if c.IsStale() {
+		return fmt.Errorf("record for container %v is stale: bundle %v does not exist", id, c.Bundle)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
@@ -0,0 +1,129 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+type command struct{}
+
+type options struct {
+	stateDir string
+	json     bool
+}
+
+// NewCommand constructs a list command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	list := cli.Command{
+		Name:  "list",
+		Usage: "List the containers and the resources injected into them",
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	list.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "state-dir",
+			Usage:       "the directory of the container records. Defaults to the statedir from the runtime config",
+			Destination: &opts.stateDir,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "output the records as JSON",
+			Destination: &opts.json,
+		},
+	}
+
+	return &list
+}
+
+func (m command) run(opts *options) error {
+	stateDir := opts.stateDir
+	if stateDir == "" {
+		cfg, err := config.LoadConfigFromFile(config.GetConfigFilePath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		stateDir = cfg.StateDir
+	}
+
+	store := state.New(stateDir)
+	if _, err := store.RemoveStale(); err != nil {
+		return fmt.Errorf("failed to remove stale container records: %v", err)
+	}
+	containers, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if containers == nil {
+			containers = []*state.Container{}
+		}
+		return encoder.Encode(containers)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tDEVICES\tSDK IMAGE\tCREATED")
+	for _, c := range containers {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", c.ID, devices(c), valueOrNone(c.SdkImage), c.Created.Local().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// devices returns the injected devices as a comma-separated list.
+func devices(c *state.Container) string {
+	var devices []string
+	for _, d := range c.Devices {
+		if d.UUID != "" {
+			devices = append(devices, fmt.Sprintf("%d (%v)", d.Index, d.UUID))
+		} else {
+			devices = append(devices, fmt.Sprintf("%d", d.Index))
+		}
+	}
+	devices = append(devices, c.CDIDevices...)
+	return valueOrNone(strings.Join(devices, ","))
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
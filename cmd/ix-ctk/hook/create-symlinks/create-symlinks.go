@@ -0,0 +1,148 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package createsymlinks
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/hook"
+	"gitee.com/deep-spark/ix-container-runtime/internal/rootfs"
+)
+
+type command struct{}
+
+type options struct {
+	links cli.StringSlice
+}
+
+// NewCommand constructs a create-symlinks command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	c := cli.Command{
+		Name:  "create-symlinks",
+		Usage: "Create symlinks in the root filesystem of a container. Runs as a createContainer OCI hook and reads the container state from STDIN",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(&opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "link",
+			Usage:       "a link to create in the container, specified as target::link. May be specified multiple times",
+			Destination: &opts.links,
+		},
+	}
+
+	return &c
+}
+
+func (m command) validateFlags(opts *options) error {
+	for _, l := range opts.links.Value() {
+		if _, _, err := parseLink(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m command) run(opts *options) error {
+	state, err := hook.ReadState(os.Stdin)
+	if err != nil {
+		return err
+	}
+	root, err := hook.ContainerRoot(state)
+	if err != nil {
+		return fmt.Errorf("failed to determine container root: %v", err)
+	}
+
+	for _, l := range opts.links.Value() {
+		target, link, err := parseLink(l)
+		if err != nil {
+			return err
+		}
+		err = createLink(root, target, link)
+		if err != nil {
+			return fmt.Errorf("failed to create link %v: %v", link, err)
+		}
+	}
+	return nil
+}
+
+// parseLink splits a link specified as target::link. The link must be an
+// absolute container path.
+func parseLink(l string) (string, string, error) {
+	target, link, found := strings.Cut(l, "::")
+	if !found || target == "" || link == "" {
+		return "", "", fmt.Errorf("invalid link %q: expected target::link", l)
+	}
+	if !filepath.IsAbs(link) {
+		return "", "", fmt.Errorf("invalid link %q: link must be an absolute path", l)
+	}
+	return target, filepath.Clean(link), nil
+}
+
+// createLink creates the link to the target in the root filesystem. The parent
+// directory of the link is resolved within the root filesystem so that symlinks
+// in the container cannot redirect the link outside of it. The target is stored
+// as-is and is only interpreted in the container. Existing files are not
+// replaced.
+func createLink(root string, target string, link string) error {
+	parent, err := rootfs.Resolve(root, filepath.Dir(link))
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(parent, filepath.Base(link))
+
+	info, err := os.Lstat(path)
+	if err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			if current, err := os.Readlink(path); err == nil && current == target {
+				return nil
+			}
+		}
+		log.Printf("Not creating link %v to %v: file exists", link, target)
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.MkdirAll(parent, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create parent directory: %v", err)
+	}
+
+	log.Printf("Creating link %v to %v", link, target)
+	return os.Symlink(target, path)
+}
@@ -0,0 +1,49 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hook
+
+import (
+	"github.com/urfave/cli/v2"
+
+	createsymlinks "gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/hook/create-symlinks"
+	updateldcache "gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/hook/update-ldcache"
+)
+
+type command struct {
+}
+
+// NewCommand constructs a hook command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	// Create the 'hook' command
+	hook := cli.Command{
+		Name:  "hook",
+		Usage: "A collection of OCI hooks that the IX Container Toolkit adds to containers",
+	}
+
+	hook.Subcommands = []*cli.Command{
+		createsymlinks.NewCommand(),
+		updateldcache.NewCommand(),
+	}
+
+	return &hook
+}
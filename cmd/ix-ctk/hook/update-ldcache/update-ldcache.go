@@ -0,0 +1,218 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package updateldcache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/atomicfile"
+	"gitee.com/deep-spark/ix-container-runtime/internal/hook"
+	"gitee.com/deep-spark/ix-container-runtime/internal/rootfs"
+)
+
+const (
+	// ldsoconfdDir is the directory of the linker configuration files in the container.
+	ldsoconfdDir = "/etc/ld.so.conf.d"
+	// ldsoconfdFileFormat is the format of the name of the linker configuration
+	// file written for the folders. The name is derived from the folders so that
+	// several hooks do not overwrite each other, while running a hook again (e.g.
+	// when a container is restarted) replaces its file.
+	ldsoconfdFileFormat = "00-ix-container-toolkit-%x.conf"
+)
+
+// defaultLdconfigPaths are the container paths searched for ldconfig. On
+// Debian-based images /sbin/ldconfig is a wrapper script for ldconfig.real.
+var defaultLdconfigPaths = []string{
+	"/sbin/ldconfig.real",
+	"/sbin/ldconfig",
+	"/usr/sbin/ldconfig",
+}
+
+type command struct{}
+
+type options struct {
+	folders      cli.StringSlice
+	ldconfigPath string
+}
+
+// NewCommand constructs an update-ldcache command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	c := cli.Command{
+		Name:  "update-ldcache",
+		Usage: "Update the linker cache of a container for the injected libraries. Runs as a createContainer OCI hook and reads the container state from STDIN",
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "folder",
+			Usage:       "a container folder to add to the linker cache. May be specified multiple times",
+			Destination: &opts.folders,
+		},
+		&cli.StringFlag{
+			Name:        "ldconfig-path",
+			Usage:       "the container path of ldconfig. If this is '' the default locations are searched",
+			Destination: &opts.ldconfigPath,
+		},
+	}
+
+	return &c
+}
+
+func (m command) run(opts *options) error {
+	state, err := hook.ReadState(os.Stdin)
+	if err != nil {
+		return err
+	}
+	root, err := hook.ContainerRoot(state)
+	if err != nil {
+		return fmt.Errorf("failed to determine container root: %v", err)
+	}
+
+	folders, err := existingFolders(root, opts.folders.Value())
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		log.Printf("No folders to add to the linker cache")
+		return nil
+	}
+
+	ldconfig, err := findLdconfig(root, opts.ldconfigPath)
+	if err != nil {
+		return err
+	}
+	if ldconfig == "" {
+		log.Printf("No ldconfig found in container; not updating the linker cache")
+		return nil
+	}
+
+	err = writeConfig(root, folders)
+	if err != nil {
+		return err
+	}
+
+	return runLdconfig(root, ldconfig, folders)
+}
+
+// existingFolders returns the folders that exist as directories in the root
+// filesystem of the container.
+func existingFolders(root string, folders []string) ([]string, error) {
+	var existing []string
+	for _, f := range folders {
+		path, err := rootfs.Resolve(root, f)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			log.Printf("Ignoring folder %v: not a directory in the container", f)
+			continue
+		}
+		existing = append(existing, filepath.Clean("/"+f))
+	}
+	return existing, nil
+}
+
+// findLdconfig returns the container path of ldconfig or an empty path if it
+// does not exist in the container.
+func findLdconfig(root string, ldconfigPath string) (string, error) {
+	candidates := defaultLdconfigPaths
+	if ldconfigPath != "" {
+		candidates = []string{ldconfigPath}
+	}
+	for _, c := range candidates {
+		path, err := rootfs.Resolve(root, c)
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+		return c, nil
+	}
+	return "", nil
+}
+
+// writeConfig writes the folders to the file for the folders in the
+// ld.so.conf.d directory of the container.
+func writeConfig(root string, folders []string) error {
+	dir, err := rootfs.Resolve(root, ldsoconfdDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %v: %v", ldsoconfdDir, err)
+	}
+
+	content := strings.Join(folders, "\n") + "\n"
+	path := filepath.Join(dir, configFileName(content))
+	// The config is read by unprivileged users of the container.
+	err = atomicfile.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write linker config file: %v", err)
+	}
+	log.Printf("Wrote folders %v to %v", folders, path)
+	return nil
+}
+
+// configFileName returns the name of the linker configuration file with the
+// specified content.
+func configFileName(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf(ldsoconfdFileFormat, sum[:4])
+}
+
+// runLdconfig runs the ldconfig of the container chrooted into its root
+// filesystem. The folders are also passed on the command line so that they are
+// cached even if the ld.so.conf of the container does not include ld.so.conf.d.
+func runLdconfig(root string, ldconfig string, folders []string) error {
+	cmd := exec.Command(ldconfig, folders...)
+	cmd.Dir = "/"
+	cmd.Env = []string{"PATH=/usr/sbin:/usr/bin:/sbin:/bin"}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Chroot: root}
+
+	log.Printf("Running %v %v in %v", ldconfig, folders, root)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to run %v: %v", ldconfig, err)
+	}
+	return nil
+}
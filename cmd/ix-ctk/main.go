@@ -22,6 +22,9 @@ import (
 	"os"
 
 	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/cdi"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/config"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/containers"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/hook"
 	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/runtime"
 	"github.com/urfave/cli/v2"
 )
@@ -34,6 +37,9 @@ func main() {
 		Commands: []*cli.Command{
 			runtime.NewCommand(),
 			cdi.NewCommand(),
+			containers.NewCommand(),
+			config.NewCommand(),
+			hook.NewCommand(),
 		},
 	}
 
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"log"
 
+	runtimeconfig "gitee.com/deep-spark/ix-container-runtime/internal/config"
 	"gitee.com/deep-spark/ix-container-runtime/internal/config/engine"
 	"gitee.com/deep-spark/ix-container-runtime/internal/config/engine/containerd"
 	"gitee.com/deep-spark/ix-container-runtime/internal/config/engine/crio"
@@ -33,9 +34,6 @@ const (
 	defaultContainerdConfigFilePath = "/etc/containerd/config.toml"
 	defaultCrioConfigFilePath       = "/etc/crio/crio.conf"
 	defaultDockerConfigFilePath     = "/etc/docker/daemon.json"
-	defaultConfigFilePath           = "/etc/iluvatarcorex/ix-container-runtime/config.yaml"
-	defaultConfigFileContent        = `librarypath: /usr/local/corex/lib64/libixml.so
-sdksocketpath: /run/ix-sdk-manager/ix-sdk.sock`
 )
 
 type command struct {
@@ -171,10 +169,46 @@ func ConfigureRuntime(c config) error {
 		log.Printf("It is recommended that %v daemon be restarted.\n", c.runtime)
 	}
 
-	_, err = engine.Config(defaultConfigFilePath).Write([]byte(defaultConfigFileContent))
+	err = updateRuntimeConfig(c.dryRun)
 	if err != nil {
-		return fmt.Errorf("unable to flush config: %v", defaultConfigFilePath)
+		return err
 	}
 
 	return nil
 }
+
+// updateRuntimeConfig adds the settings required by the ix-container-runtime to
+// its config file. Settings that are already present are not changed.
+func updateRuntimeConfig(dryRun bool) error {
+	f, err := runtimeconfig.LoadFile(runtimeconfig.GetConfigFilePath())
+	if err != nil {
+		return fmt.Errorf("unable to load runtime config: %v", err)
+	}
+
+	defaults := []struct {
+		key   string
+		value string
+	}{
+		{"librarypath", runtimeconfig.DefaultLibraryPath},
+		{"sdksocketpath", runtimeconfig.DefaultSdkSocketPath},
+	}
+
+	var updated bool
+	for _, d := range defaults {
+		added, err := f.SetDefault(d.key, d.value)
+		if err != nil {
+			return fmt.Errorf("unable to update runtime config: %v", err)
+		}
+		updated = updated || added
+	}
+
+	if !updated || dryRun {
+		return nil
+	}
+	err = f.Save()
+	if err != nil {
+		return fmt.Errorf("unable to flush config: %v", f.Path())
+	}
+	log.Printf("Wrote updated runtime config to %v\n", f.Path())
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/runtime/configure"
+	"gitee.com/deep-spark/ix-container-runtime/cmd/ix-ctk/runtime/simulate"
 )
 
 type runtimeCommand struct {
@@ -41,6 +42,7 @@ func (m runtimeCommand) build() *cli.Command {
 
 	runtime.Subcommands = []*cli.Command{
 		configure.NewCommand(),
+		simulate.NewCommand(),
 	}
 
 	return &runtime
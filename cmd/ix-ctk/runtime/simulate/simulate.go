@@ -0,0 +1,213 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli/v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/runtime"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type command struct{}
+
+type options struct {
+	bundle string
+	spec   string
+	config string
+	mode   string
+	format string
+}
+
+// NewCommand constructs a simulate command
+func NewCommand() *cli.Command {
+	c := command{}
+	return c.build()
+}
+
+func (m command) build() *cli.Command {
+	opts := options{}
+
+	simulate := cli.Command{
+		Name:  "simulate",
+		Usage: "Show the modifications the ix-container-runtime would make to the OCI spec of a container",
+		Before: func(c *cli.Context) error {
+			return m.validateFlags(&opts)
+		},
+		Action: func(c *cli.Context) error {
+			return m.run(&opts)
+		},
+	}
+
+	simulate.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "bundle",
+			Aliases:     []string{"b"},
+			Usage:       "the bundle directory containing the config.json of the container",
+			Destination: &opts.bundle,
+		},
+		&cli.StringFlag{
+			Name:        "spec",
+			Usage:       "the path of the OCI spec of the container. Takes precedence over --bundle",
+			Destination: &opts.spec,
+		},
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "the path of the runtime config file",
+			Value:       config.ConfigFilePath,
+			EnvVars:     []string{config.ConfigFilePathEnvvar},
+			Destination: &opts.config,
+		},
+		&cli.StringFlag{
+			Name:        "mode",
+			Usage:       "override the runtime mode from the config. One of [legacy | cdi | auto]",
+			Destination: &opts.mode,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "the output format. One of [text | json]",
+			Value:       formatText,
+			Destination: &opts.format,
+		},
+	}
+
+	return &simulate
+}
+
+func (m command) validateFlags(opts *options) error {
+	if opts.bundle == "" && opts.spec == "" {
+		return fmt.Errorf("one of --bundle or --spec must be specified")
+	}
+	if opts.mode != "" && !config.IsValidMode(opts.mode) {
+		return fmt.Errorf("invalid mode %q", opts.mode)
+	}
+	switch opts.format {
+	case formatText, formatJSON:
+	default:
+		return fmt.Errorf("invalid format %q", opts.format)
+	}
+	return nil
+}
+
+// run applies the modifier of the runtime to an in-memory copy of the OCI spec
+// and prints the resulting changes. No low-level runtime is executed and the
+// spec on disk is not changed.
+func (m command) run(opts *options) error {
+	cfg, err := config.LoadConfigFromFile(opts.config)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	specPath := opts.spec
+	if specPath == "" {
+		specPath = oci.GetSpecFilePath(opts.bundle)
+	}
+	original, err := oci.NewFileSpec(specPath).Load()
+	if err != nil {
+		return err
+	}
+
+	modified, err := oci.CopySpec(original)
+	if err != nil {
+		return err
+	}
+
+	specModifier, err := runtime.NewQueryOnlySpecModifier(cfg, opts.mode, original)
+	if err != nil {
+		return fmt.Errorf("failed to construct OCI spec modifier: %v", err)
+	}
+	if specModifier != nil {
+		err = oci.NewMemorySpec(modified).Modify(specModifier)
+		if err != nil {
+			return fmt.Errorf("failed to modify OCI spec: %v", err)
+		}
+	}
+
+	diff := oci.DiffSpecs(original, modified)
+	if opts.format == formatJSON {
+		return writeJSON(os.Stdout, diff)
+	}
+	return writeText(os.Stdout, diff)
+}
+
+func writeJSON(w io.Writer, diff *oci.SpecDiff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
+
+// writeText writes the diff in a format similar to a unified diff with one
+// added item per line.
+func writeText(w io.Writer, diff *oci.SpecDiff) error {
+	if diff.IsEmpty() {
+		_, err := fmt.Fprintln(w, "No modifications")
+		return err
+	}
+
+	var lines []string
+	for _, d := range diff.Devices {
+		lines = append(lines, fmt.Sprintf("+device %v (%v %v:%v)", d.Path, d.Type, d.Major, d.Minor))
+	}
+	for _, r := range diff.DeviceRules {
+		lines = append(lines, fmt.Sprintf("+cgroup %v", oci.DeviceRuleString(r)))
+	}
+	for _, mount := range diff.Mounts {
+		lines = append(lines, fmt.Sprintf("+mount %v:%v (%v)", mount.Source, mount.Destination, strings.Join(mount.Options, ",")))
+	}
+	for _, e := range diff.Env {
+		lines = append(lines, fmt.Sprintf("+env %v", e))
+	}
+	var stages []string
+	for stage := range diff.Hooks {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	for _, stage := range stages {
+		for _, h := range diff.Hooks[stage] {
+			lines = append(lines, fmt.Sprintf("+hook %v %v", stage, hookString(h)))
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hookString(h specs.Hook) string {
+	if len(h.Args) == 0 {
+		return h.Path
+	}
+	return h.Path + " " + strings.Join(h.Args[1:], " ")
+}
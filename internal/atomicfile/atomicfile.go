@@ -0,0 +1,70 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes the data to the specified path. The file is replaced
+// atomically by writing to a temporary file in the same directory, syncing it
+// and renaming it over the original, so that readers and a crash never observe
+// a partially written file.
+func WriteFile(path string, data []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer func() {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("error writing %v: %v", path, err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("error setting mode of %v: %v", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("error syncing %v: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing %v: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing %v: %v", path, err)
+	}
+	tmp = nil
+
+	// Sync the directory so that the rename is persisted.
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("error opening directory %v: %v", dir, err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("error syncing directory %v: %v", dir, err)
+	}
+	return nil
+}
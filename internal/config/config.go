@@ -23,13 +23,23 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/logger"
 )
 
 const (
-	cfgpath = "/etc/iluvatarcorex/ix-container-runtime/config.yaml"
+	// ConfigFilePath is the default path of the runtime config file.
+	ConfigFilePath = "/etc/iluvatarcorex/ix-container-runtime/config.yaml"
+	// ConfigFilePathEnvvar is the environment variable that overrides the path of
+	// the runtime config file.
+	ConfigFilePathEnvvar = "IX_CONTAINER_RUNTIME_CONFIG"
+	// ConfigDropInDir is the directory next to the config file from which the
+	// *.yaml files are merged into the config in lexical order.
+	ConfigDropInDir = "config.d"
 
 	LogPath = "/var/log/iluvatarcorex/ix-container-toolkit/ix-container-runtime.log"
 
@@ -40,36 +50,231 @@ const (
 	LevelError   = "error"
 	LevelFatal   = "fatal"
 	LevelPanic   = "Panic"
+
+	// LogFormatText writes log entries as text.
+	LogFormatText = "text"
+	// LogFormatJSON writes log entries as JSON objects, one per line.
+	LogFormatJSON = "json"
+
+	// DefaultLogMaxSize is the size in megabytes at which the log file is rotated.
+	DefaultLogMaxSize = 10
+	// DefaultLogMaxBackups is the number of rotated log files that are kept.
+	DefaultLogMaxBackups = 3
+	// logFileMode is the mode of the log files.
+	logFileMode = 0640
+
+	// ModeLegacy injects devices discovered through ixml based on IX_VISIBLE_DEVICES.
+	ModeLegacy = "legacy"
+	// ModeCDI injects devices resolved from CDI specifications.
+	ModeCDI = "cdi"
+	// ModeAuto selects ModeCDI if a CDI specification for DefaultCDIKind exists and ModeLegacy otherwise.
+	ModeAuto = "auto"
+
+	// DefaultCDIKind is the CDI kind of the devices generated by ix-ctk cdi generate.
+	DefaultCDIKind = "iluvatar.com/gpu"
+
+	// DeviceListStrategyEnvvar reads the requested devices from IX_VISIBLE_DEVICES.
+	DeviceListStrategyEnvvar = "envvar"
+	// DeviceListStrategyVolumeMounts reads the requested devices from the mounts under
+	// /var/run/iluvatar-container-devices and ignores IX_VISIBLE_DEVICES.
+	DeviceListStrategyVolumeMounts = "volume-mounts"
+
+	// DeviceNumberingHost exposes the injected devices with the names of their
+	// host device nodes, e.g. /dev/iluvatar2 and /dev/iluvatar5.
+	DeviceNumberingHost = "host"
+	// DeviceNumberingContiguous exposes the injected devices as /dev/iluvatar0
+	// to /dev/iluvatarN-1 in the order in which they were requested.
+	DeviceNumberingContiguous = "contiguous"
+
+	// UnprivilegedEnvvarPolicyIgnore ignores the devices requested by unprivileged containers.
+	UnprivilegedEnvvarPolicyIgnore = "ignore"
+	// UnprivilegedEnvvarPolicyReject fails the creation of unprivileged containers requesting devices.
+	UnprivilegedEnvvarPolicyReject = "reject"
+
+	// OnErrorFail rejects the container if it cannot be prepared.
+	OnErrorFail = "fail"
+	// OnErrorPassthrough execs the low-level runtime with the unmodified spec if the
+	// container cannot be prepared.
+	OnErrorPassthrough = "passthrough"
+
+	// DefaultLibraryPath is the path of libixml written to the config file by
+	// ix-ctk runtime configure.
+	DefaultLibraryPath = "/usr/local/corex/lib64/libixml.so"
+	// DefaultSdkSocketPath is the socket of the local SDK manager.
+	DefaultSdkSocketPath = "/run/ix-sdk-manager/iluvatar-sdk-local.sock"
+
+	// DefaultStateDir is the directory in which the runtime records the resources
+	// injected into each container.
+	DefaultStateDir = "/run/iluvatar/containers"
+
+	// DefaultLeaseDir is the directory in which the exclusive device leases are stored.
+	DefaultLeaseDir = "/run/iluvatar/leases"
+	// DefaultExclusiveTimeout is the time in seconds a container waits for a
+	// leased device with the wait conflict policy.
+	DefaultExclusiveTimeout = 30
+
+	// ExclusiveOnConflictReject fails the creation of a container requesting a
+	// device that is leased by another container.
+	ExclusiveOnConflictReject = "reject"
+	// ExclusiveOnConflictWait waits for the device to be released for up to the
+	// configured timeout.
+	ExclusiveOnConflictWait = "wait"
+
+	// DefaultCTKPath is the path of the ix-ctk executable used in the OCI hooks
+	// added to containers.
+	DefaultCTKPath = "/usr/local/bin/ix-ctk"
+)
+
+var (
+	// DefaultLowLevelRuntimes is the ordered list of low-level runtimes searched for in the PATH
+	// when no candidates are configured.
+	DefaultLowLevelRuntimes = []string{"docker-runc", "runc", "crun"}
+
+	// DefaultCDISpecDirs are the directories searched for CDI specifications.
+	DefaultCDISpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
 )
 
 type Config struct {
-	Loglevel      string `json:"loglevel"             yaml:"loglevel,omitempty"`
-	LogPath       string `json:"logpath"             yaml:"logpath,omitempty"`
-	LibraryPath   string `json:"librarypath"             yaml:"librarypath,omitempty"`
-	DefaultSdk    string `json:"defaultsdk" yaml:"defaultsdk"`
-	SdkSocketPath string `json:"sdksocketpath" yaml:"sdksocketpath"`
+	Loglevel        string                `json:"loglevel"             yaml:"loglevel,omitempty"`
+	LogPath         string                `json:"logpath"             yaml:"logpath,omitempty"`
+	LogFormat       string                `json:"logformat" yaml:"logformat,omitempty"`
+	LogMaxSize      int                   `json:"logmaxsize" yaml:"logmaxsize,omitempty"`
+	LogMaxBackups   int                   `json:"logmaxbackups" yaml:"logmaxbackups"`
+	LibraryPath     string                `json:"librarypath"             yaml:"librarypath,omitempty"`
+	DefaultSdk      string                `json:"defaultsdk" yaml:"defaultsdk"`
+	SdkSocketPath   string                `json:"sdksocketpath" yaml:"sdksocketpath"`
+	LowLevelRuntime LowLevelRuntimeConfig `json:"lowlevelruntime" yaml:"lowlevelruntime,omitempty"`
+	Mode            string                `json:"mode" yaml:"mode,omitempty"`
+	CDI             CDIConfig             `json:"cdi" yaml:"cdi,omitempty"`
+
+	DeviceListStrategy string `json:"deviceliststrategy" yaml:"deviceliststrategy,omitempty"`
+	// DeviceListAnnotations are the OCI annotation keys from which requested devices
+	// are read. If any of these annotations is set, it takes precedence over the
+	// device list strategy.
+	DeviceListAnnotations []string `json:"devicelistannotations" yaml:"devicelistannotations,omitempty"`
+
+	// DeviceNumbering defines the names of the device nodes of the devices
+	// injected in the legacy mode.
+	DeviceNumbering string `json:"devicenumbering" yaml:"devicenumbering,omitempty"`
+
+	// AcceptEnvvarUnprivileged controls whether devices requested through
+	// IX_VISIBLE_DEVICES are honored for unprivileged containers.
+	AcceptEnvvarUnprivileged bool `json:"accept-envvar-unprivileged" yaml:"accept-envvar-unprivileged"`
+	// UnprivilegedEnvvarPolicy defines how a device request through IX_VISIBLE_DEVICES
+	// from an unprivileged container is handled if AcceptEnvvarUnprivileged is false.
+	UnprivilegedEnvvarPolicy string `json:"unprivileged-envvar-policy" yaml:"unprivileged-envvar-policy,omitempty"`
+
+	// OnError defines how errors encountered while preparing a container are handled.
+	OnError string `json:"on-error" yaml:"on-error,omitempty"`
+
+	// StateDir is the directory in which the per-container records are stored.
+	StateDir string `json:"statedir" yaml:"statedir,omitempty"`
+
+	// CTKPath is the path of the ix-ctk executable used in the OCI hooks added to
+	// containers, e.g. to update the linker cache of the container.
+	CTKPath string `json:"ctkpath" yaml:"ctkpath,omitempty"`
+
+	// Exclusive holds the settings of the exclusive device mode.
+	Exclusive ExclusiveConfig `json:"exclusive" yaml:"exclusive,omitempty"`
+}
+
+// LowLevelRuntimeConfig holds the settings used to select the low-level runtime
+// that ix-container-runtime execs into.
+type LowLevelRuntimeConfig struct {
+	// Runtimes is the ordered list of candidate runtimes. Names are searched for
+	// in the PATH; absolute paths are used as-is.
+	Runtimes []string `json:"runtimes" yaml:"runtimes,omitempty"`
+	// Path is the absolute path to the low-level runtime. If set, Runtimes is ignored.
+	Path string `json:"path" yaml:"path,omitempty"`
+	// Args are extra arguments passed to the default low-level runtime ahead of
+	// the arguments received from the container engine.
+	Args []string `json:"args" yaml:"args,omitempty"`
+	// AllowedRuntimes maps the names that containers may request through the
+	// low-level runtime annotation to the runtime executable (name or absolute path).
+	AllowedRuntimes map[string]string `json:"allowedruntimes" yaml:"allowedruntimes,omitempty"`
+}
+
+// CDIConfig holds the settings used to resolve devices from CDI specifications.
+type CDIConfig struct {
+	// SpecDirs are the directories searched for CDI specifications.
+	SpecDirs []string `json:"specdirs" yaml:"specdirs,omitempty"`
+	// DefaultKind is the kind used to qualify device names requested through IX_VISIBLE_DEVICES.
+	DefaultKind string `json:"defaultkind" yaml:"defaultkind,omitempty"`
+	// AcceptEnvvarDevices enables the injection of fully-qualified CDI device names
+	// (e.g. iluvatar.com/gpu=0) from IX_VISIBLE_DEVICES in the legacy mode.
+	AcceptEnvvarDevices bool `json:"acceptenvvardevices" yaml:"acceptenvvardevices,omitempty"`
+}
+
+// ExclusiveConfig holds the settings used to lease each device to at most one
+// container at a time.
+type ExclusiveConfig struct {
+	// Enabled makes the runtime take an exclusive lease on each device injected
+	// into a container.
+	Enabled bool `json:"enabled" yaml:"enabled,omitempty"`
+	// OnConflict defines how a request for a device leased by another container
+	// is handled.
+	OnConflict string `json:"onconflict" yaml:"onconflict,omitempty"`
+	// Timeout is the time in seconds to wait for a leased device with the wait policy.
+	Timeout int `json:"timeout" yaml:"timeout"`
+	// LeaseDir is the directory in which the leases are stored.
+	LeaseDir string `json:"leasedir" yaml:"leasedir,omitempty"`
+}
+
+// IsValidMode checks whether the specified mode is supported.
+func IsValidMode(mode string) bool {
+	switch mode {
+	case ModeLegacy, ModeCDI, ModeAuto:
+		return true
+	}
+	return false
 }
 
 func parseConfigFrom(reader io.Reader) (*Config, error) {
-	var err error
-	var configYaml []byte
+	cfg := defaultConfig()
+	err := parseConfigInto(cfg, reader)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
-	configYaml, err = io.ReadAll(reader)
+// parseConfigInto reads the config from the reader into the specified config.
+// Only the settings present in the reader are changed.
+func parseConfigInto(cfg *Config, reader io.Reader) error {
+	configYaml, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("read error: %v", err)
+		return fmt.Errorf("read error: %v", err)
 	}
 
-	var cfg Config
-	err = yaml.Unmarshal(configYaml, &cfg)
+	err = yaml.Unmarshal(configYaml, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshal error: %v", err)
+		return fmt.Errorf("unmarshal error: %v", err)
 	}
+	return nil
+}
 
-	return &cfg, nil
+// defaultConfig returns the config used as the base when loading a config file.
+// Only settings whose zero value is not the default need to be set here; the
+// remaining defaults are applied by update.
+func defaultConfig() *Config {
+	return &Config{
+		AcceptEnvvarUnprivileged: true,
+		LogMaxBackups:            DefaultLogMaxBackups,
+		Exclusive: ExclusiveConfig{
+			Timeout: DefaultExclusiveTimeout,
+		},
+	}
 }
 
-func (c *Config) update() error {
-	var level log.Level
+// DefaultConfig returns the config used if no config file exists.
+func DefaultConfig() *Config {
+	cfg := defaultConfig()
+	cfg.update()
+	return cfg
+}
+
+// update applies the defaults for settings that are not specified.
+func (c *Config) update() {
 	if c.LogPath == "" {
 		c.LogPath = LogPath
 	}
@@ -78,6 +283,70 @@ func (c *Config) update() error {
 		c.Loglevel = LevelInfo
 	}
 
+	if c.SdkSocketPath == "" {
+		c.SdkSocketPath = DefaultSdkSocketPath
+	}
+
+	if c.LogFormat == "" {
+		c.LogFormat = LogFormatText
+	}
+
+	if c.LogMaxSize == 0 {
+		c.LogMaxSize = DefaultLogMaxSize
+	}
+
+	if len(c.LowLevelRuntime.Runtimes) == 0 {
+		c.LowLevelRuntime.Runtimes = DefaultLowLevelRuntimes
+	}
+
+	if c.Mode == "" {
+		c.Mode = ModeLegacy
+	}
+
+	if len(c.CDI.SpecDirs) == 0 {
+		c.CDI.SpecDirs = DefaultCDISpecDirs
+	}
+
+	if c.CDI.DefaultKind == "" {
+		c.CDI.DefaultKind = DefaultCDIKind
+	}
+
+	if c.DeviceListStrategy == "" {
+		c.DeviceListStrategy = DeviceListStrategyEnvvar
+	}
+
+	if c.DeviceNumbering == "" {
+		c.DeviceNumbering = DeviceNumberingHost
+	}
+
+	if c.UnprivilegedEnvvarPolicy == "" {
+		c.UnprivilegedEnvvarPolicy = UnprivilegedEnvvarPolicyIgnore
+	}
+
+	if c.OnError == "" {
+		c.OnError = OnErrorFail
+	}
+
+	if c.StateDir == "" {
+		c.StateDir = DefaultStateDir
+	}
+
+	if c.CTKPath == "" {
+		c.CTKPath = DefaultCTKPath
+	}
+
+	if c.Exclusive.OnConflict == "" {
+		c.Exclusive.OnConflict = ExclusiveOnConflictReject
+	}
+
+	if c.Exclusive.LeaseDir == "" {
+		c.Exclusive.LeaseDir = DefaultLeaseDir
+	}
+}
+
+// setupLogging configures the logger as specified by the config.
+func (c *Config) setupLogging() error {
+	var level log.Level
 	switch c.Loglevel {
 	case LevelInfo:
 		level = log.InfoLevel
@@ -105,45 +374,115 @@ func (c *Config) update() error {
 		}
 	}
 
-	reader, err := os.OpenFile(c.LogPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	output, err := logger.NewRotatingFile(c.LogPath, int64(c.LogMaxSize)*1024*1024, c.LogMaxBackups, logFileMode)
 	if err != nil {
-		return fmt.Errorf("error opening config file: %v", err)
+		return err
 	}
-	log.SetOutput(reader)
+	log.SetOutput(output)
 
 	// 启用日志行号
 	log.SetReportCaller(true)
 
+	// 返回 "function" 和 "file:line" 的格式
+	callerPrettyfier := func(f *runtime.Frame) (string, string) {
+		return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+
 	// 自定义日志格式，包括文件名和行号
-	log.SetFormatter(&log.TextFormatter{
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			// 返回 "function" 和 "file:line" 的格式
-			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", f.File, f.Line)
-		},
-		FullTimestamp: true,
-	})
+	switch c.LogFormat {
+	case LogFormatJSON:
+		log.SetFormatter(&log.JSONFormatter{
+			CallerPrettyfier: callerPrettyfier,
+		})
+	default:
+		log.SetFormatter(&log.TextFormatter{
+			CallerPrettyfier: callerPrettyfier,
+			FullTimestamp:    true,
+		})
+	}
 	return nil
 }
 
+// GetConfigFilePath returns the path of the runtime config file. The path can
+// be overridden through the IX_CONTAINER_RUNTIME_CONFIG environment variable.
+func GetConfigFilePath() string {
+	if path := os.Getenv(ConfigFilePathEnvvar); path != "" {
+		return path
+	}
+	return ConfigFilePath
+}
+
+// LoadConfig loads the runtime config file and configures logging as specified
+// by it.
 func LoadConfig() (*Config, error) {
-	var cfg *Config
-	reader, err := os.Open(cfgpath)
+	return LoadConfigWithPath("")
+}
+
+// LoadConfigWithPath loads the runtime config from the specified file and
+// configures logging as specified by it. If the path is empty, the path from
+// GetConfigFilePath is used.
+func LoadConfigWithPath(path string) (*Config, error) {
+	if path == "" {
+		path = GetConfigFilePath()
+	}
+	cfg, err := LoadConfigFromFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			cfg = &Config{}
-		} else {
-			return nil, fmt.Errorf("error opening config file: %v", err)
-		}
-	} else {
-		defer reader.Close()
-		cfg, err = parseConfigFrom(reader)
+		return nil, err
+	}
+	err = cfg.setupLogging()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFromFile loads the config from the specified file and applies the
+// defaults. If the file does not exist, the default config is used. The files in
+// the config.d directory next to the file are merged in lexical order, followed
+// by the IX_CTK_<KEY> environment overrides. Logging is not configured.
+func LoadConfigFromFile(path string) (*Config, error) {
+	cfg := defaultConfig()
+	err := mergeConfigFile(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+
+	dropIns, err := filepath.Glob(filepath.Join(filepath.Dir(path), ConfigDropInDir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing config drop-in files: %v", err)
+	}
+	sort.Strings(dropIns)
+	for _, dropIn := range dropIns {
+		err := mergeConfigFile(cfg, dropIn)
 		if err != nil {
 			return nil, err
 		}
 	}
-	err = cfg.update()
+
+	err = applyEnvOverrides(cfg, os.LookupEnv)
 	if err != nil {
 		return nil, err
 	}
+
+	cfg.update()
 	return cfg, nil
 }
+
+// mergeConfigFile reads the config file at the specified path into the config.
+// A missing file is ignored.
+func mergeConfigFile(cfg *Config, path string) error {
+	reader, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening config file: %v", err)
+	}
+	defer reader.Close()
+
+	err = parseConfigInto(cfg, reader)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %v: %v", path, err)
+	}
+	return nil
+}
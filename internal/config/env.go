@@ -0,0 +1,118 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envOverridePrefix is the prefix of the environment variables that override
+// config settings. The variable for a setting is the prefix followed by its key
+// in upper case with dashes replaced by underscores; keys of nested settings are
+// joined by underscores, e.g. IX_CTK_LOGLEVEL, IX_CTK_ON_ERROR or
+// IX_CTK_CDI_SPECDIRS.
+const envOverridePrefix = "IX_CTK_"
+
+// applyEnvOverrides sets the config settings for which an override is found
+// through the lookup function. Lists are comma-separated and maps are
+// comma-separated key=value pairs.
+func applyEnvOverrides(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnvOverridesTo(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(envOverridePrefix, "_"), lookup)
+}
+
+func applyEnvOverridesTo(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(strings.ReplaceAll(tag, "-", "_"))
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnvOverridesTo(v.Field(i), key, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		if err := setFromString(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid value %q for %v: %v", value, key, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		m := make(map[string]string)
+		for _, pair := range splitList(value) {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty elements.
+func splitList(value string) []string {
+	var list []string
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
@@ -0,0 +1,126 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	testCases := []struct {
+		description string
+		env         map[string]string
+		expected    func(*Config)
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expected:    func(*Config) {},
+		},
+		{
+			description: "string, bool and int settings",
+			env: map[string]string{
+				"IX_CTK_LOGLEVEL":                   "debug",
+				"IX_CTK_ACCEPT_ENVVAR_UNPRIVILEGED": "false",
+				"IX_CTK_LOGMAXSIZE":                 "20",
+			},
+			expected: func(c *Config) {
+				c.Loglevel = "debug"
+				c.AcceptEnvvarUnprivileged = false
+				c.LogMaxSize = 20
+			},
+		},
+		{
+			description: "dashes in keys",
+			env:         map[string]string{"IX_CTK_ON_ERROR": "passthrough"},
+			expected: func(c *Config) {
+				c.OnError = "passthrough"
+			},
+		},
+		{
+			description: "nested settings",
+			env: map[string]string{
+				"IX_CTK_CDI_DEFAULTKIND":    "iluvatar.com/gpu",
+				"IX_CTK_EXCLUSIVE_ENABLED":  "true",
+				"IX_CTK_EXCLUSIVE_LEASEDIR": "/leases",
+			},
+			expected: func(c *Config) {
+				c.CDI.DefaultKind = "iluvatar.com/gpu"
+				c.Exclusive.Enabled = true
+				c.Exclusive.LeaseDir = "/leases"
+			},
+		},
+		{
+			description: "lists drop empty elements",
+			env:         map[string]string{"IX_CTK_CDI_SPECDIRS": "/etc/cdi, ,/var/run/cdi,"},
+			expected: func(c *Config) {
+				c.CDI.SpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+			},
+		},
+		{
+			description: "maps",
+			env:         map[string]string{"IX_CTK_LOWLEVELRUNTIME_ALLOWEDRUNTIMES": "crun=/usr/bin/crun, runc = /usr/bin/runc"},
+			expected: func(c *Config) {
+				c.LowLevelRuntime.AllowedRuntimes = map[string]string{"crun": "/usr/bin/crun", "runc": "/usr/bin/runc"}
+			},
+		},
+		{
+			description: "invalid bool",
+			env:         map[string]string{"IX_CTK_ACCEPT_ENVVAR_UNPRIVILEGED": "maybe"},
+			expectError: true,
+		},
+		{
+			description: "invalid int",
+			env:         map[string]string{"IX_CTK_LOGMAXSIZE": "large"},
+			expectError: true,
+		},
+		{
+			description: "invalid map",
+			env:         map[string]string{"IX_CTK_LOWLEVELRUNTIME_ALLOWEDRUNTIMES": "crun"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				value, ok := tc.env[key]
+				return value, ok
+			}
+
+			cfg := defaultConfig()
+			err := applyEnvOverrides(cfg, lookup)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := defaultConfig()
+			tc.expected(expected)
+			if !reflect.DeepEqual(cfg, expected) {
+				t.Errorf("expected %+v, got %+v", expected, cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+	yaml2 "sigs.k8s.io/yaml/goyaml.v2"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/atomicfile"
+)
+
+// File is a config file edited as is: defaults are not applied, so that only
+// the settings present in the file are written back. Settings are addressed by
+// their keys joined by dots, e.g. loglevel or cdi.specdirs.
+type File struct {
+	path   string
+	values map[string]interface{}
+	// order is the content of the file as loaded, used to retain the order of
+	// the settings when the file is saved.
+	order yaml2.MapSlice
+}
+
+// LoadFile loads the config file at the specified path. A missing file results
+// in an empty config file.
+func LoadFile(path string) (*File, error) {
+	f := &File{
+		path:   path,
+		values: make(map[string]interface{}),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return f, nil
+	}
+	err = yaml.Unmarshal(data, &f.values)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %v: %v", path, err)
+	}
+	err = yaml2.Unmarshal(data, &f.order)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %v: %v", path, err)
+	}
+	if f.values == nil {
+		f.values = make(map[string]interface{})
+	}
+	return f, nil
+}
+
+// Path returns the path of the config file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Has checks whether the setting is present in the file.
+func (f *File) Has(key string) bool {
+	_, ok := lookupKey(f.values, strings.Split(key, "."))
+	return ok
+}
+
+// Set sets the setting to the specified value. The value is converted to the
+// type of the setting; lists are comma-separated and maps are comma-separated
+// key=value pairs.
+func (f *File) Set(key string, value string) error {
+	t, err := keyType(key)
+	if err != nil {
+		return err
+	}
+	v := reflect.New(t).Elem()
+	err = setFromString(v, value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %v: %v", value, key, err)
+	}
+	f.set(strings.Split(key, "."), v.Interface())
+	return nil
+}
+
+// SetDefault sets the setting to the specified value if it is not present in
+// the file. It returns whether the setting was added.
+func (f *File) SetDefault(key string, value string) (bool, error) {
+	if f.Has(key) {
+		return false, nil
+	}
+	return true, f.Set(key, value)
+}
+
+func (f *File) set(path []string, value interface{}) {
+	values := f.values
+	for _, p := range path[:len(path)-1] {
+		next, ok := values[p].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[p] = next
+		}
+		values = next
+	}
+	values[path[len(path)-1]] = value
+}
+
+// UnknownKeys returns the keys in the file that do not correspond to a setting.
+func (f *File) UnknownKeys() []string {
+	var unknown []string
+	collectUnknownKeys(f.values, reflect.TypeOf(Config{}), "", &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+func collectUnknownKeys(values map[string]interface{}, t reflect.Type, prefix string, unknown *[]string) {
+	fields := jsonFields(t)
+	for k, v := range values {
+		field, ok := fields[k]
+		if !ok {
+			*unknown = append(*unknown, prefix+k)
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok && field.Type.Kind() == reflect.Struct {
+			collectUnknownKeys(nested, field.Type, prefix+k+".", unknown)
+		}
+	}
+}
+
+// Save writes the config file. The settings are written in the order of the
+// loaded file, followed by the added settings. Comments are not retained. The
+// file is replaced atomically, so that the runtime never reads a partially
+// written config.
+func (f *File) Save() error {
+	data, err := yaml2.Marshal(orderedValues(f.values, f.order))
+	if err != nil {
+		return fmt.Errorf("error encoding config file: %v", err)
+	}
+
+	path := f.path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to create directory %v: %v", filepath.Dir(path), err)
+	}
+	err = atomicfile.WriteFile(path, data, mode)
+	if err != nil {
+		return fmt.Errorf("error writing config file: %v", err)
+	}
+	return nil
+}
+
+// orderedValues returns the settings in the order of the loaded settings, with
+// settings that were not loaded appended in lexical order.
+func orderedValues(values map[string]interface{}, order yaml2.MapSlice) yaml2.MapSlice {
+	var ordered yaml2.MapSlice
+	seen := make(map[string]bool)
+	for _, item := range order {
+		key := fmt.Sprint(item.Key)
+		value, ok := values[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		ordered = append(ordered, yaml2.MapItem{Key: key, Value: orderedValue(value, item.Value)})
+	}
+
+	var added []string
+	for key := range values {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		ordered = append(ordered, yaml2.MapItem{Key: key, Value: orderedValue(values[key], nil)})
+	}
+	return ordered
+}
+
+func orderedValue(value interface{}, loaded interface{}) interface{} {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	order, _ := loaded.(yaml2.MapSlice)
+	return orderedValues(nested, order)
+}
+
+// Get returns the value of the setting in the config.
+func (c *Config) Get(key string) (interface{}, error) {
+	if _, err := keyType(key); err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	value, _ := lookupKey(values, strings.Split(key, "."))
+	return value, nil
+}
+
+func lookupKey(values map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := values[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupKey(nested, path[1:])
+}
+
+// keyType returns the type of the setting with the specified key.
+func keyType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, p := range strings.Split(key, ".") {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown config key %q", key)
+		}
+		field, ok := jsonFields(t)[p]
+		if !ok {
+			return nil, fmt.Errorf("unknown config key %q", key)
+		}
+		t = field.Type
+	}
+	if t.Kind() == reflect.Struct {
+		return nil, fmt.Errorf("config key %q is not a setting", key)
+	}
+	return t, nil
+}
+
+// jsonFields returns the fields of the struct type keyed by their JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
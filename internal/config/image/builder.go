@@ -26,9 +26,11 @@ import (
 )
 
 type builder struct {
-	env    map[string]string
-	mounts []specs.Mount
-	Cfg    *config.Config
+	env          map[string]string
+	mounts       []specs.Mount
+	annotations  map[string]string
+	isPrivileged bool
+	Cfg          *config.Config
 }
 
 // New creates a new CUDA image from the input options.
@@ -49,9 +51,11 @@ func New(opt ...Option) (CUDA, error) {
 // build creates a CUDA image from the builder.
 func (b builder) build() (CUDA, error) {
 	c := CUDA{
-		env:    b.env,
-		mounts: b.mounts,
-		Cfg:    b.Cfg,
+		env:          b.env,
+		mounts:       b.mounts,
+		annotations:  b.annotations,
+		isPrivileged: b.isPrivileged,
+		Cfg:          b.Cfg,
 	}
 	return c, nil
 }
@@ -90,6 +94,23 @@ func WithMounts(mounts []specs.Mount) Option {
 		return nil
 	}
 }
+
+// WithAnnotations sets the OCI annotations associated with the CUDA image.
+func WithAnnotations(annotations map[string]string) Option {
+	return func(b *builder) error {
+		b.annotations = annotations
+		return nil
+	}
+}
+
+// WithPrivileged sets whether the CUDA image is used in a privileged container.
+func WithPrivileged(isPrivileged bool) Option {
+	return func(b *builder) error {
+		b.isPrivileged = isPrivileged
+		return nil
+	}
+}
+
 func WithConfig(cfg *config.Config) Option {
 	return func(b *builder) error {
 		b.Cfg = cfg
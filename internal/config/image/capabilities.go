@@ -0,0 +1,86 @@
+/*
+*
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+*
+*/
+package image
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EnvVarIXDriverCapabilities is the environment variable used to request the
+	// host driver files injected into the container.
+	EnvVarIXDriverCapabilities = "IX_DRIVER_CAPABILITIES"
+
+	// DriverCapabilityAll selects all driver capabilities.
+	DriverCapabilityAll DriverCapability = "all"
+	// DriverCapabilityCompute selects the libraries required to run compute workloads.
+	DriverCapabilityCompute DriverCapability = "compute"
+	// DriverCapabilityUtility selects the management library and tools such as ixsmi.
+	DriverCapabilityUtility DriverCapability = "utility"
+)
+
+// DriverCapability is a set of host driver files that can be requested.
+type DriverCapability string
+
+// DriverCapabilities is the set of requested driver capabilities.
+type DriverCapabilities map[DriverCapability]bool
+
+// NewDriverCapabilities parses a comma-separated list of driver capabilities.
+// Unknown capabilities are ignored.
+func NewDriverCapabilities(value string) DriverCapabilities {
+	c := make(DriverCapabilities)
+	for _, v := range strings.Split(value, ",") {
+		capability := DriverCapability(strings.TrimSpace(v))
+		switch capability {
+		case "":
+		case DriverCapabilityAll:
+			c[DriverCapabilityCompute] = true
+			c[DriverCapabilityUtility] = true
+		case DriverCapabilityCompute, DriverCapabilityUtility:
+			c[capability] = true
+		default:
+			log.Warnf("Ignoring unknown driver capability %q", capability)
+		}
+	}
+	return c
+}
+
+// Has checks whether the capability is requested.
+func (c DriverCapabilities) Has(capability DriverCapability) bool {
+	return c[capability]
+}
+
+// List returns the requested capabilities in sorted order.
+func (c DriverCapabilities) List() []string {
+	var list []string
+	for capability := range c {
+		list = append(list, string(capability))
+	}
+	sort.Strings(list)
+	return list
+}
+
+// DriverCapabilities returns the driver capabilities requested through
+// IX_DRIVER_CAPABILITIES. No capabilities are requested if it is not set.
+func (i CUDA) DriverCapabilities() DriverCapabilities {
+	return NewDriverCapabilities(i.env[EnvVarIXDriverCapabilities])
+}
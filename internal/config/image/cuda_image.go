@@ -18,19 +18,27 @@
 package image
 
 import (
+	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	log "github.com/sirupsen/logrus"
+
 	"gitee.com/deep-spark/ix-container-runtime/internal/config"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
 )
 
 // CUDA represents a CUDA image that can be used for GPU computing. This wraps
 // a map of environment variable to values that can be used to perform lookups
 // such as requirements.
 type CUDA struct {
-	env    map[string]string
-	mounts []specs.Mount
-	Cfg    *config.Config
+	env          map[string]string
+	mounts       []specs.Mount
+	annotations  map[string]string
+	isPrivileged bool
+	Cfg          *config.Config
 }
 
 // NewCUDAImageFromSpec creates a CUDA image from the input OCI runtime spec.
@@ -44,10 +52,168 @@ func NewCUDAImageFromSpec(spec *specs.Spec, cfg *config.Config) (CUDA, error) {
 	return New(
 		WithEnv(env),
 		WithMounts(spec.Mounts),
+		WithAnnotations(spec.Annotations),
+		WithPrivileged(IsPrivileged(spec)),
 		WithConfig(cfg),
 	)
 }
 
+// IsPrivileged returns true if the container described by the spec has the
+// CAP_SYS_ADMIN capability in its bounding set.
+func IsPrivileged(spec *specs.Spec) bool {
+	if spec == nil || spec.Process == nil || spec.Process.Capabilities == nil {
+		return false
+	}
+	for _, c := range spec.Process.Capabilities.Bounding {
+		if c == capSysAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// EnvVarIXVisibleDevices is the environment variable used to request devices.
+	EnvVarIXVisibleDevices = "IX_VISIBLE_DEVICES"
+	// EnvVarIXRequestedDevices holds the original device request if the runtime
+	// renumbered the devices and rewrote IX_VISIBLE_DEVICES to the in-container
+	// view. It takes precedence over IX_VISIBLE_DEVICES so that applying the
+	// modifications again to an already modified spec selects the same devices.
+	EnvVarIXRequestedDevices = "IX_REQUESTED_DEVICES"
+
+	// DeviceListAsVolumeMountsRoot is the container path under which the device
+	// plugin mounts one entry per requested device when the volume-mounts device
+	// list strategy is used.
+	DeviceListAsVolumeMountsRoot = "/var/run/iluvatar-container-devices"
+	// deviceListAsVolumeMountsSource is the host path the device plugin mounts
+	// for each requested device.
+	deviceListAsVolumeMountsSource = "/dev/null"
+
+	capSysAdmin = "CAP_SYS_ADMIN"
+)
+
+// VisibleDevices returns the devices requested for the container. The following
+// sources are considered in order of precedence:
+//  1. the configured device list annotations, if any of them is set;
+//  2. the mounts under DeviceListAsVolumeMountsRoot, if the volume-mounts strategy is selected;
+//  3. the IX_REQUESTED_DEVICES or, if unset, the IX_VISIBLE_DEVICES environment variable.
+//
+// This ensures that assignments made by cluster components through annotations
+// cannot be overridden from the container image or the pod environment.
+// Requests through IX_VISIBLE_DEVICES from unprivileged containers are subject
+// to the accept-envvar-unprivileged setting.
+func (i CUDA) VisibleDevices() (VisibleDevices, error) {
+	envVar := i.requestEnvvar()
+	if i.Cfg == nil {
+		return i.DevicesFromEnvvars(envVar), nil
+	}
+	if devices := i.DevicesFromAnnotations(i.Cfg.DeviceListAnnotations...); devices != nil {
+		return devices, nil
+	}
+	if i.Cfg.DeviceListStrategy == config.DeviceListStrategyVolumeMounts {
+		return i.DevicesFromMounts(), nil
+	}
+
+	devices := i.DevicesFromEnvvars(envVar)
+	if i.isPrivileged || i.Cfg.AcceptEnvvarUnprivileged {
+		return devices, nil
+	}
+
+	// An unset envvar selects all devices for legacy images. This is not an
+	// explicit request and is therefore never rejected.
+	_, isSet := i.env[envVar]
+	requested := devices.List()
+	isRequest := isSet && len(requested) > 0 && requested[0] != ""
+	if isRequest && i.Cfg.UnprivilegedEnvvarPolicy == config.UnprivilegedEnvvarPolicyReject {
+		return nil, fmt.Errorf("%v is not accepted for unprivileged containers", envVar)
+	}
+	log.Infof("Ignoring %v for unprivileged container", envVar)
+	return NewVisibleDevices("void"), nil
+}
+
+// requestEnvvar returns the environment variable from which the requested
+// devices are read.
+func (i CUDA) requestEnvvar() string {
+	if _, ok := i.env[EnvVarIXRequestedDevices]; ok {
+		return EnvVarIXRequestedDevices
+	}
+	return EnvVarIXVisibleDevices
+}
+
+// DevicesFromAnnotations returns the devices requested through the specified
+// annotations. If none of the annotations is set, nil is returned.
+func (i CUDA) DevicesFromAnnotations(keys ...string) VisibleDevices {
+	var isSet bool
+	var devices []string
+	for _, key := range keys {
+		value, ok := i.annotations[key]
+		if !ok {
+			continue
+		}
+		isSet = true
+		for _, d := range strings.Split(value, ",") {
+			trimmed := strings.TrimSpace(d)
+			if len(trimmed) == 0 {
+				continue
+			}
+			devices = append(devices, trimmed)
+		}
+	}
+
+	if !isSet {
+		return nil
+	}
+	if len(devices) == 0 {
+		return NewVisibleDevices("void")
+	}
+	return NewVisibleDevices(devices...)
+}
+
+// DevicesFromMounts returns the devices requested through mounts directly under
+// DeviceListAsVolumeMountsRoot. The base name of each mount destination is the
+// requested device ID. Only mounts of /dev/null, as created by the device
+// plugin, are considered, so that a container cannot request devices by
+// mounting a volume of its own. If no such mounts exist, no devices are
+// requested.
+func (i CUDA) DevicesFromMounts() VisibleDevices {
+	root := filepath.Clean(DeviceListAsVolumeMountsRoot)
+
+	var devices []string
+	seen := make(map[string]bool)
+	for _, m := range i.mounts {
+		destination := filepath.Clean(m.Destination)
+		if filepath.Dir(destination) != root {
+			continue
+		}
+		if m.Source == "" || filepath.Clean(m.Source) != deviceListAsVolumeMountsSource {
+			log.Warnf("Ignoring device list mount %v with source %q", destination, m.Source)
+			continue
+		}
+		id := filepath.Base(destination)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		devices = append(devices, id)
+	}
+
+	if len(devices) == 0 {
+		return NewVisibleDevices("void")
+	}
+	return NewVisibleDevices(devices...)
+}
+
+// CDIDevicesFromAnnotations returns the fully-qualified CDI device names requested
+// through cdi.k8s.io/* annotations.
+func (i CUDA) CDIDevicesFromAnnotations() ([]string, error) {
+	_, devices, err := cdi.ParseAnnotations(i.annotations)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(devices)
+	return devices, nil
+}
+
 func (i CUDA) DevicesFromEnvvars(envVars ...string) VisibleDevices {
 	// We concantenate all the devices from the specified env.
 	var isSet bool
@@ -0,0 +1,115 @@
+/*
+*
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+*
+*/
+
+package image
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestDevicesFromMounts(t *testing.T) {
+	testCases := []struct {
+		description string
+		mounts      []specs.Mount
+		expected    []string
+	}{
+		{
+			description: "no mounts",
+			expected:    nil,
+		},
+		{
+			description: "device plugin mounts",
+			mounts: []specs.Mount{
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices/0"},
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices/GPU-1234"},
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices/0"},
+			},
+			expected: []string{"0", "GPU-1234"},
+		},
+		{
+			description: "uncleaned paths",
+			mounts: []specs.Mount{
+				{Source: "/dev//null", Destination: "/var/run/iluvatar-container-devices/./1/"},
+			},
+			expected: []string{"1"},
+		},
+		{
+			description: "mounts outside the root are ignored",
+			mounts: []specs.Mount{
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices"},
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices/all/0"},
+				{Source: "/dev/null", Destination: "/var/run/other/1"},
+			},
+			expected: nil,
+		},
+		{
+			description: "emptyDir volume is rejected",
+			mounts: []specs.Mount{
+				{Source: "/var/lib/kubelet/pods/uid/volumes/kubernetes.io~empty-dir/devices", Destination: "/var/run/iluvatar-container-devices/all", Type: "bind"},
+			},
+			expected: nil,
+		},
+		{
+			description: "hostPath volume is rejected",
+			mounts: []specs.Mount{
+				{Source: "/tmp", Destination: "/var/run/iluvatar-container-devices/all", Type: "bind"},
+			},
+			expected: nil,
+		},
+		{
+			description: "tmpfs is rejected",
+			mounts: []specs.Mount{
+				{Source: "tmpfs", Destination: "/var/run/iluvatar-container-devices/all", Type: "tmpfs"},
+			},
+			expected: nil,
+		},
+		{
+			description: "mount without source is rejected",
+			mounts: []specs.Mount{
+				{Destination: "/var/run/iluvatar-container-devices/all"},
+			},
+			expected: nil,
+		},
+		{
+			description: "sources are compared after cleaning",
+			mounts: []specs.Mount{
+				{Source: "/dev/shm/../null", Destination: "/var/run/iluvatar-container-devices/1"},
+				{Source: "/dev/null", Destination: "/var/run/iluvatar-container-devices/0"},
+				{Source: "/dev/null.d", Destination: "/var/run/iluvatar-container-devices/all"},
+			},
+			expected: []string{"1", "0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			image, err := New(WithMounts(tc.mounts))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			devices := image.DevicesFromMounts().List()
+			if !slices.Equal(devices, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, devices)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+*
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+*
+*/
+package image
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EnvVarIXRequirePrefix is the prefix of the environment variables that
+	// declare requirements on the host, e.g. IX_REQUIRE_DRIVER=driver>=4.1.
+	EnvVarIXRequirePrefix = "IX_REQUIRE_"
+	// EnvVarIXDisableRequire disables the checks of the declared requirements.
+	EnvVarIXDisableRequire = "IX_DISABLE_REQUIRE"
+
+	// AnnotationRequirePrefix is the prefix of the OCI annotations that declare
+	// requirements on the host, e.g. iluvatar.com/require-driver=driver>=4.1.
+	AnnotationRequirePrefix = "iluvatar.com/require-"
+	// AnnotationDisableRequire disables the checks of the declared requirements.
+	AnnotationDisableRequire = "iluvatar.com/disable-require"
+)
+
+// Requirements returns the requirements declared through the IX_REQUIRE_*
+// environment variables and the iluvatar.com/require-* annotations, ordered by
+// name. Empty requirements are skipped.
+func (i CUDA) Requirements() []string {
+	var keys []string
+	values := make(map[string]string)
+	for key, value := range i.env {
+		if strings.HasPrefix(key, EnvVarIXRequirePrefix) {
+			keys = append(keys, "env:"+key)
+			values["env:"+key] = value
+		}
+	}
+	for key, value := range i.annotations {
+		if strings.HasPrefix(key, AnnotationRequirePrefix) {
+			keys = append(keys, "annotation:"+key)
+			values["annotation:"+key] = value
+		}
+	}
+	sort.Strings(keys)
+
+	var requirements []string
+	for _, key := range keys {
+		if value := strings.TrimSpace(values[key]); value != "" {
+			requirements = append(requirements, value)
+		}
+	}
+	return requirements
+}
+
+// RequirementsDisabled checks whether the checks of the declared requirements
+// are disabled through IX_DISABLE_REQUIRE or the iluvatar.com/disable-require
+// annotation.
+func (i CUDA) RequirementsDisabled() bool {
+	if value, ok := i.annotations[AnnotationDisableRequire]; ok && isTrue(AnnotationDisableRequire, value) {
+		return true
+	}
+	if value, ok := i.env[EnvVarIXDisableRequire]; ok && isTrue(EnvVarIXDisableRequire, value) {
+		return true
+	}
+	return false
+}
+
+// isTrue parses the boolean value of the named setting. Invalid values are
+// treated as false.
+func isTrue(name string, value string) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		log.Warnf("Ignoring invalid value %q for %v", value, name)
+		return false
+	}
+	return b
+}
@@ -0,0 +1,99 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// Validate checks the settings of the config and returns the problems found.
+// Paths that are configured are checked to be readable on the host.
+func (c *Config) Validate() []error {
+	var errs []error
+
+	switch c.Loglevel {
+	case LevelInfo, LevelDebug, LevelTrace, LevelWarning, LevelError, LevelFatal, LevelPanic:
+	default:
+		errs = append(errs, fmt.Errorf("invalid loglevel %q", c.Loglevel))
+	}
+	switch c.LogFormat {
+	case LogFormatText, LogFormatJSON:
+	default:
+		errs = append(errs, fmt.Errorf("invalid logformat %q", c.LogFormat))
+	}
+	if !IsValidMode(c.Mode) {
+		errs = append(errs, fmt.Errorf("invalid mode %q", c.Mode))
+	}
+	switch c.DeviceListStrategy {
+	case DeviceListStrategyEnvvar, DeviceListStrategyVolumeMounts:
+	default:
+		errs = append(errs, fmt.Errorf("invalid deviceliststrategy %q", c.DeviceListStrategy))
+	}
+	switch c.DeviceNumbering {
+	case DeviceNumberingHost, DeviceNumberingContiguous:
+	default:
+		errs = append(errs, fmt.Errorf("invalid devicenumbering %q", c.DeviceNumbering))
+	}
+	switch c.UnprivilegedEnvvarPolicy {
+	case UnprivilegedEnvvarPolicyIgnore, UnprivilegedEnvvarPolicyReject:
+	default:
+		errs = append(errs, fmt.Errorf("invalid unprivileged-envvar-policy %q", c.UnprivilegedEnvvarPolicy))
+	}
+	switch c.OnError {
+	case OnErrorFail, OnErrorPassthrough:
+	default:
+		errs = append(errs, fmt.Errorf("invalid on-error %q", c.OnError))
+	}
+
+	switch c.Exclusive.OnConflict {
+	case ExclusiveOnConflictReject, ExclusiveOnConflictWait:
+	default:
+		errs = append(errs, fmt.Errorf("invalid exclusive.onconflict %q", c.Exclusive.OnConflict))
+	}
+	if c.Exclusive.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid exclusive.timeout %v", c.Exclusive.Timeout))
+	}
+
+	if c.LibraryPath != "" {
+		f, err := os.Open(c.LibraryPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("librarypath is not readable: %v", err))
+		} else {
+			f.Close()
+		}
+	}
+
+	info, err := os.Stat(c.SdkSocketPath)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("sdksocketpath is not accessible: %v", err))
+	} else if info.Mode()&os.ModeSocket == 0 {
+		errs = append(errs, fmt.Errorf("sdksocketpath %v is not a socket", c.SdkSocketPath))
+	}
+
+	if c.CTKPath != "" {
+		info, err := os.Stat(c.CTKPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ctkpath is not accessible: %v", err))
+		} else if info.Mode()&0111 == 0 {
+			errs = append(errs, fmt.Errorf("ctkpath %v is not executable", c.CTKPath))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,373 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/rootfs"
+)
+
+// edits are the changes made by the modifier that have to be applied to a live
+// container.
+type edits struct {
+	*oci.SpecDiff
+	// existingRules are the device cgroup rules of the original spec.
+	existingRules []specs.LinuxDeviceCgroup
+}
+
+// newEdits returns the changes between the original and the modified OCI spec.
+func newEdits(original *specs.Spec, modified *specs.Spec) *edits {
+	e := &edits{SpecDiff: oci.DiffSpecs(original, modified)}
+	if original.Linux != nil && original.Linux.Resources != nil {
+		e.existingRules = original.Linux.Resources.Devices
+	}
+	return e
+}
+
+// apply applies the edits to the container with the specified pid and root
+// filesystem.
+func (e *edits) apply(pid int, rootfs string) error {
+	if len(e.Hooks) > 0 {
+		log.Warnf("Hooks cannot be added to a running container and are ignored: %v", e.Hooks)
+	}
+	if len(e.Env) > 0 {
+		log.Warnf("Environment changes cannot be applied to a running container and are ignored: %v", e.Env)
+	}
+
+	err := applyDeviceRules(pid, e.existingRules, e.DeviceRules)
+	if err != nil {
+		return err
+	}
+
+	if len(e.Devices) == 0 && len(e.Mounts) == 0 {
+		return nil
+	}
+
+	return inMountNamespace(pid, func() error {
+		for _, d := range e.Devices {
+			if err := createDevice(rootfs, d); err != nil {
+				return err
+			}
+		}
+		for _, m := range e.Mounts {
+			if err := bindMount(rootfs, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// inMountNamespace runs the specified function in the mount namespace of the
+// process with the specified pid. The function is run on a dedicated OS thread
+// that is not returned to the scheduler, since it cannot leave the namespace.
+func inMountNamespace(pid int, fn func() error) error {
+	errCh := make(chan error)
+	go func() {
+		goruntime.LockOSThread()
+
+		// setns for a mount namespace requires the thread not to share its
+		// filesystem attributes with other threads.
+		err := unix.Unshare(unix.CLONE_FS)
+		if err != nil {
+			errCh <- fmt.Errorf("failed to unshare filesystem attributes: %v", err)
+			return
+		}
+
+		nsPath := fmt.Sprintf("/proc/%d/ns/mnt", pid)
+		ns, err := os.Open(nsPath)
+		if err != nil {
+			errCh <- fmt.Errorf("failed to open mount namespace: %v", err)
+			return
+		}
+		defer ns.Close()
+
+		err = unix.Setns(int(ns.Fd()), unix.CLONE_NEWNS)
+		if err != nil {
+			errCh <- fmt.Errorf("failed to join mount namespace %v: %v", nsPath, err)
+			return
+		}
+
+		errCh <- fn()
+	}()
+	return <-errCh
+}
+
+// createDevice creates the specified device node in the root filesystem. An
+// existing node with the same type and number is kept, while any other file at
+// the path is replaced.
+func createDevice(root string, d specs.LinuxDevice) error {
+	path, err := rootfs.Resolve(root, d.Path)
+	if err != nil {
+		return err
+	}
+
+	var mode uint32
+	switch d.Type {
+	case "c", "u":
+		mode = unix.S_IFCHR
+	case "b":
+		mode = unix.S_IFBLK
+	case "p":
+		mode = unix.S_IFIFO
+	default:
+		return fmt.Errorf("unsupported type %q for device %v", d.Type, d.Path)
+	}
+	rdev := unix.Mkdev(uint32(d.Major), uint32(d.Minor))
+
+	var stat unix.Stat_t
+	err = unix.Lstat(path, &stat)
+	switch {
+	case err == nil && stat.Mode&unix.S_IFMT == mode && (mode == unix.S_IFIFO || uint64(stat.Rdev) == rdev):
+		log.Infof("Device %v already exists", d.Path)
+		return nil
+	case err == nil && stat.Mode&unix.S_IFMT == unix.S_IFDIR:
+		return fmt.Errorf("failed to create device %v: path is a directory", d.Path)
+	case err == nil:
+		// The path is a different device or another file, which is replaced
+		// so that the container does not access the wrong node.
+		log.Warnf("Replacing %v (%v:%v) with device %v:%v", d.Path, unix.Major(uint64(stat.Rdev)), unix.Minor(uint64(stat.Rdev)), d.Major, d.Minor)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove existing device %v: %v", d.Path, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to check device %v: %v", d.Path, err)
+	}
+
+	perm := os.FileMode(0666)
+	if d.FileMode != nil {
+		perm = *d.FileMode
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create parent directory for device %v: %v", d.Path, err)
+	}
+
+	log.Infof("Creating device %v (%v:%v)", d.Path, d.Major, d.Minor)
+	err = unix.Mknod(path, mode|uint32(perm.Perm()), int(rdev))
+	if err != nil {
+		return fmt.Errorf("failed to create device %v: %v", d.Path, err)
+	}
+	// mknod is subject to the umask
+	err = os.Chmod(path, perm.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to set mode of device %v: %v", d.Path, err)
+	}
+
+	if d.UID != nil || d.GID != nil {
+		uid, gid := -1, -1
+		if d.UID != nil {
+			uid = int(*d.UID)
+		}
+		if d.GID != nil {
+			gid = int(*d.GID)
+		}
+		err = os.Lchown(path, uid, gid)
+		if err != nil {
+			return fmt.Errorf("failed to set owner of device %v: %v", d.Path, err)
+		}
+	}
+	return nil
+}
+
+// bindMount creates the specified bind mount in the root filesystem. Mounts of
+// other types are not supported and are skipped.
+func bindMount(root string, m specs.Mount) error {
+	flags, supported := mountFlags(m)
+	if !supported {
+		log.Warnf("Skipping unsupported mount of %v to %v with type %q", m.Source, m.Destination, m.Type)
+		return nil
+	}
+
+	info, err := os.Stat(m.Source)
+	if err != nil {
+		return fmt.Errorf("failed to stat mount source %v: %v", m.Source, err)
+	}
+
+	target, err := rootfs.Resolve(root, m.Destination)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0755)
+	} else {
+		err = createFile(target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create mount point %v: %v", m.Destination, err)
+	}
+
+	log.Infof("Mounting %v to %v", m.Source, m.Destination)
+	bindFlags := uintptr(unix.MS_BIND) | flags&unix.MS_REC
+	err = unix.Mount(m.Source, target, "", bindFlags, "")
+	if err != nil {
+		return fmt.Errorf("failed to mount %v to %v: %v", m.Source, m.Destination, err)
+	}
+
+	// Flags other than MS_BIND and MS_REC are ignored for the initial bind mount
+	// and are applied by remounting.
+	remountFlags := flags &^ (unix.MS_BIND | unix.MS_REC)
+	if remountFlags != 0 {
+		err = unix.Mount("", target, "", uintptr(unix.MS_BIND|unix.MS_REMOUNT|remountFlags), "")
+		if err != nil {
+			return fmt.Errorf("failed to remount %v: %v", m.Destination, err)
+		}
+	}
+	return nil
+}
+
+// mountFlags returns the flags for the options of the specified mount and
+// whether it is a bind mount.
+func mountFlags(m specs.Mount) (uintptr, bool) {
+	var flags uintptr
+	isBind := m.Type == "bind"
+	for _, o := range m.Options {
+		switch o {
+		case "bind":
+			isBind = true
+			flags |= unix.MS_BIND
+		case "rbind":
+			isBind = true
+			flags |= unix.MS_BIND | unix.MS_REC
+		case "ro":
+			flags |= unix.MS_RDONLY
+		case "nosuid":
+			flags |= unix.MS_NOSUID
+		case "nodev":
+			flags |= unix.MS_NODEV
+		case "noexec":
+			flags |= unix.MS_NOEXEC
+		}
+	}
+	return flags, isBind
+}
+
+func createFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// applyDeviceRules allows the specified devices in the devices cgroup of the
+// process with the specified pid. Only cgroup v1 is supported; with cgroup v2 the
+// device controller is implemented in eBPF and cannot be updated here, so an
+// error is returned unless the rules are already covered by the existing rules.
+func applyDeviceRules(pid int, existing []specs.LinuxDeviceCgroup, rules []specs.LinuxDeviceCgroup) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
+		var missing []string
+		for _, r := range rules {
+			if !isAllowed(existing, r) {
+				missing = append(missing, oci.DeviceRuleString(r))
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("device cgroup rules %q cannot be applied on cgroup v2 and must be allowed by the container engine", missing)
+		}
+		return nil
+	}
+
+	cgroupPath, err := devicesCgroupPath(pid)
+	if err != nil {
+		return err
+	}
+	allowPath := filepath.Join("/sys/fs/cgroup/devices", cgroupPath, "devices.allow")
+	for _, r := range rules {
+		rule := oci.DeviceRuleString(r)
+		log.Infof("Allowing device rule %q for container", rule)
+		err := os.WriteFile(allowPath, []byte(rule), 0)
+		if err != nil {
+			return fmt.Errorf("failed to write device rule %q to %v: %v", rule, allowPath, err)
+		}
+	}
+	return nil
+}
+
+// isAllowed checks whether the access granted by the specified allow rule is
+// already granted by the existing rules. Only the allow rules following the last
+// deny rule are considered.
+func isAllowed(existing []specs.LinuxDeviceCgroup, rule specs.LinuxDeviceCgroup) bool {
+	for i := len(existing) - 1; i >= 0; i-- {
+		e := existing[i]
+		if !e.Allow {
+			return false
+		}
+		if e.Type != "a" && e.Type != "" && e.Type != rule.Type {
+			continue
+		}
+		if !coversID(e.Major, rule.Major) || !coversID(e.Minor, rule.Minor) {
+			continue
+		}
+		if e.Access != "" && strings.Trim(rule.Access, e.Access) != "" {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// coversID checks whether the major or minor number of an existing rule covers
+// that of another rule. A nil or negative number is a wildcard.
+func coversID(existing *int64, id *int64) bool {
+	if existing == nil || *existing < 0 {
+		return true
+	}
+	return id != nil && *id == *existing
+}
+
+// devicesCgroupPath returns the path of the devices cgroup v1 of the specified process.
+func devicesCgroupPath(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroups of process %d: %v", pid, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			if controller == "devices" {
+				return parts[2], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no devices cgroup found for process %d", pid)
+}
@@ -0,0 +1,163 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/logger"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/runtime"
+)
+
+// ReadState reads the container state that is passed to OCI hooks on stdin.
+func ReadState(reader io.Reader) (*specs.State, error) {
+	var state specs.State
+	err := json.NewDecoder(reader).Decode(&state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container state: %v", err)
+	}
+	if state.Bundle == "" {
+		return nil, fmt.Errorf("container state does not specify a bundle")
+	}
+	if state.Pid <= 0 {
+		return nil, fmt.Errorf("container state does not specify a valid pid")
+	}
+	return &state, nil
+}
+
+// Run applies the modifications of the runtime to the live container described
+// by the specified state. The OCI spec of the container is loaded from its bundle
+// and the same modifier as in the runtime is applied to a copy of it. The changes
+// between the two are then applied to the container: device nodes are created and
+// bind mounts are made in the mount namespace of the container, and the devices
+// are allowed in its devices cgroup.
+//
+// The hook must run before the container's root filesystem is pivoted, i.e. as a
+// prestart or createRuntime hook.
+func Run(cfg *config.Config, state *specs.State) error {
+	err := run(cfg, state)
+	var unmet *requirementsError
+	var exclusive *exclusiveError
+	if errors.As(err, &unmet) || errors.As(err, &exclusive) {
+		return err
+	}
+	if err != nil && cfg.OnError == config.OnErrorPassthrough {
+		log.Warnf("Ignoring error due to on-error=%v policy: %v", config.OnErrorPassthrough, err)
+		return nil
+	}
+	return err
+}
+
+// requirementsError is returned if the requirements declared by the container
+// are not met. It is not subject to the on-error policy.
+type requirementsError struct {
+	err error
+}
+
+func (e *requirementsError) Error() string {
+	return fmt.Sprintf("container requirements not met: %v", e.err)
+}
+
+// exclusiveError is returned if devices would be injected while exclusive
+// device leases are enabled, since the hook cannot lease devices. It is not
+// subject to the on-error policy.
+type exclusiveError struct {
+	devices []string
+}
+
+func (e *exclusiveError) Error() string {
+	return fmt.Sprintf("exclusive device leases are not supported by the OCI hook; not injecting devices %v", e.devices)
+}
+
+func run(cfg *config.Config, state *specs.State) error {
+	log.AddHook(logger.NewContextHook(log.Fields{
+		"container-id": state.ID,
+		"bundle":       state.Bundle,
+	}))
+	log.Infof("Running hook for container %v in %v", state.ID, state.Bundle)
+
+	spec, err := oci.NewFileSpec(oci.GetSpecFilePath(state.Bundle)).Load()
+	if err != nil {
+		return err
+	}
+	rootfs, err := rootfsPath(state.Bundle, spec)
+	if err != nil {
+		return err
+	}
+
+	err = runtime.CheckRequirements(cfg, spec)
+	if err != nil {
+		return &requirementsError{err}
+	}
+
+	modified, err := oci.CopySpec(spec)
+	if err != nil {
+		return err
+	}
+
+	specModifier, err := runtime.NewSpecModifier(cfg, "", spec)
+	if err != nil {
+		return fmt.Errorf("failed to construct OCI spec modifier: %v", err)
+	}
+	err = oci.NewMemorySpec(modified).Modify(specModifier)
+	if err != nil {
+		return fmt.Errorf("failed to modify OCI spec: %v", err)
+	}
+
+	edits := newEdits(spec, modified)
+	if cfg.Exclusive.Enabled && len(edits.Devices) > 0 {
+		var devices []string
+		for _, d := range edits.Devices {
+			devices = append(devices, d.Path)
+		}
+		return &exclusiveError{devices}
+	}
+	return edits.apply(state.Pid, rootfs)
+}
+
+// ContainerRoot returns the path of the root filesystem of the container
+// described by the specified state as read from the OCI spec in its bundle.
+func ContainerRoot(state *specs.State) (string, error) {
+	spec, err := oci.NewFileSpec(oci.GetSpecFilePath(state.Bundle)).Load()
+	if err != nil {
+		return "", err
+	}
+	return rootfsPath(state.Bundle, spec)
+}
+
+// rootfsPath returns the path of the root filesystem in the spec. A relative
+// path is relative to the bundle.
+func rootfsPath(bundle string, spec *specs.Spec) (string, error) {
+	if spec.Root == nil || spec.Root.Path == "" {
+		return "", fmt.Errorf("OCI spec does not specify a root filesystem")
+	}
+	rootfs := spec.Root.Path
+	if !filepath.IsAbs(rootfs) {
+		rootfs = filepath.Join(bundle, rootfs)
+	}
+	return rootfs, nil
+}
@@ -0,0 +1,286 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/atomicfile"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+const (
+	// lockFile is the file in the lease directory that is locked while leases
+	// are checked or changed.
+	lockFile = ".lock"
+
+	// retryInterval is the interval at which a conflicting lease is checked
+	// again while waiting for it to be released.
+	retryInterval = 500 * time.Millisecond
+)
+
+// Lease is the exclusive lease of a device by a container.
+type Lease struct {
+	UUID        string    `json:"uuid"`
+	ContainerID string    `json:"containerId"`
+	Bundle      string    `json:"bundle"`
+	Created     time.Time `json:"created"`
+}
+
+// ConflictError is returned if a device is leased by another container.
+type ConflictError struct {
+	UUID  string
+	Owner string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("device %v is leased by container %v", e.UUID, e.Owner)
+}
+
+// Manager manages the device leases stored as JSON files, one per device UUID,
+// in a directory. All changes are made while holding an exclusive lock on the
+// directory so that concurrent runtime invocations see a consistent view.
+type Manager struct {
+	dir   string
+	store *state.Store
+}
+
+// New creates a manager for the leases in the specified directory. A lease is
+// stale, and may be taken over, if the bundle of its owner no longer exists or
+// the store holds no record for its owner.
+func New(dir string, store *state.Store) *Manager {
+	return &Manager{
+		dir:   dir,
+		store: store,
+	}
+}
+
+// Acquire leases the devices with the specified UUIDs to the container. Either
+// all devices are leased or none is. A *ConflictError is returned if a device
+// is leased by another container. Leases already held by the container are
+// renewed.
+func (m *Manager) Acquire(containerID string, bundle string, uuids []string) error {
+	return m.locked(func() error {
+		for _, uuid := range uuids {
+			l, err := m.get(uuid)
+			if err != nil {
+				return err
+			}
+			if l == nil || l.ContainerID == containerID {
+				continue
+			}
+			if m.isStale(l) {
+				log.Infof("Taking over stale lease of device %v from container %v", uuid, l.ContainerID)
+				continue
+			}
+			return &ConflictError{UUID: uuid, Owner: l.ContainerID}
+		}
+
+		now := time.Now().UTC()
+		for i, uuid := range uuids {
+			l := &Lease{
+				UUID:        uuid,
+				ContainerID: containerID,
+				Bundle:      bundle,
+				Created:     now,
+			}
+			if err := m.save(l); err != nil {
+				for _, saved := range uuids[:i] {
+					if rerr := m.remove(saved); rerr != nil {
+						log.Warnf("Failed to roll back lease of device %v: %v", saved, rerr)
+					}
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AcquireWithTimeout leases the devices like Acquire, but waits for up to the
+// specified timeout for conflicting leases to be released.
+func (m *Manager) AcquireWithTimeout(containerID string, bundle string, uuids []string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for waiting := false; ; waiting = true {
+		err := m.Acquire(containerID, bundle, uuids)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) || !time.Now().Before(deadline) {
+			return err
+		}
+		if !waiting {
+			log.Infof("Waiting up to %v for lease: %v", timeout, err)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Release releases all leases held by the container and returns the UUIDs of
+// the released devices.
+func (m *Manager) Release(containerID string) ([]string, error) {
+	var released []string
+	err := m.locked(func() error {
+		leases, err := m.List()
+		if err != nil {
+			return err
+		}
+		for _, l := range leases {
+			if l.ContainerID != containerID {
+				continue
+			}
+			if err := m.remove(l.UUID); err != nil {
+				return err
+			}
+			released = append(released, l.UUID)
+		}
+		return nil
+	})
+	return released, err
+}
+
+// List returns all leases ordered by device UUID. Leases that cannot be read
+// are skipped with a warning.
+func (m *Manager) List() ([]*Lease, error) {
+	entries, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease directory %v: %v", m.dir, err)
+	}
+
+	var leases []*Lease
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		l, err := m.get(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			log.Warnf("Ignoring lease: %v", err)
+			continue
+		}
+		if l != nil {
+			leases = append(leases, l)
+		}
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].UUID < leases[j].UUID
+	})
+	return leases, nil
+}
+
+// isStale checks whether the owner of the lease no longer exists.
+func (m *Manager) isStale(l *Lease) bool {
+	if l.Bundle != "" {
+		if _, err := os.Stat(l.Bundle); os.IsNotExist(err) {
+			return true
+		}
+	}
+	if m.store == nil {
+		return false
+	}
+	c, err := m.store.Get(l.ContainerID)
+	if err != nil {
+		log.Warnf("Unable to read record of container %v: %v", l.ContainerID, err)
+		return false
+	}
+	return c == nil
+}
+
+// locked runs the function while holding the lock on the lease directory.
+func (m *Manager) locked(fn func() error) error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return fmt.Errorf("unable to create directory %v: %v", m.dir, err)
+	}
+	lock, err := os.OpenFile(filepath.Join(m.dir, lockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open lease lock: %v", err)
+	}
+	defer lock.Close()
+
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("error locking lease directory: %v", err)
+	}
+	defer unix.Flock(int(lock.Fd()), unix.LOCK_UN)
+
+	return fn()
+}
+
+// path returns the path of the lease for the device UUID.
+func (m *Manager) path(uuid string) (string, error) {
+	if uuid == "" || uuid == "." || uuid == ".." || strings.HasPrefix(uuid, ".") || strings.ContainsRune(uuid, os.PathSeparator) {
+		return "", fmt.Errorf("invalid device UUID %q", uuid)
+	}
+	return filepath.Join(m.dir, uuid+".json"), nil
+}
+
+func (m *Manager) get(uuid string) (*Lease, error) {
+	path, err := m.path(uuid)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease %v: %v", path, err)
+	}
+	var l Lease
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to decode lease %v: %v", path, err)
+	}
+	return &l, nil
+}
+
+func (m *Manager) save(l *Lease) error {
+	path, err := m.path(l.UUID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode lease of device %v: %v", l.UUID, err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write lease of device %v: %v", l.UUID, err)
+	}
+	return nil
+}
+
+func (m *Manager) remove(uuid string) error {
+	path, err := m.path(uuid)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lease of device %v: %v", uuid, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package logger
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// ContextHook is a logrus hook that adds a fixed set of fields, such as the
+// container ID, to every log entry.
+type ContextHook struct {
+	fields log.Fields
+}
+
+// NewContextHook creates a hook that adds the specified fields. Empty values are
+// omitted.
+func NewContextHook(fields log.Fields) *ContextHook {
+	h := ContextHook{
+		fields: make(log.Fields),
+	}
+	for k, v := range fields {
+		if s, ok := v.(string); ok && s == "" {
+			continue
+		}
+		h.fields[k] = v
+	}
+	return &h
+}
+
+// Levels returns the levels for which the hook is fired.
+func (h *ContextHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire adds the fields to the entry. Fields set on the entry take precedence.
+func (h *ContextHook) Fire(entry *log.Entry) error {
+	for k, v := range h.fields {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
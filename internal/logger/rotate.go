@@ -0,0 +1,181 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// RotatingFile is an io.Writer that appends to a log file and rotates it once it
+// exceeds a maximum size. Writes and rotations are serialized across processes
+// through an flock on a lock file next to the log file, since every runtime
+// invocation is a separate process writing to the same file.
+type RotatingFile struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	mode       os.FileMode
+
+	file *os.File
+	lock *os.File
+}
+
+// NewRotatingFile opens the log file at the specified path. If maxSize is not
+// positive the file is never rotated. At most maxBackups rotated files are kept
+// as <path>.1 (the most recent) to <path>.<maxBackups>.
+func NewRotatingFile(path string, maxSize int64, maxBackups int, mode os.FileMode) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		mode:       mode,
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, mode)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log lock file: %v", err)
+	}
+	if err := applyMode(lock, mode); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	f.lock = lock
+
+	if err := f.open(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the log file, creating it if required. The configured mode is also
+// applied to an existing file since OpenFile only uses it on creation and is
+// subject to the umask.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.mode)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	if err := applyMode(file, f.mode); err != nil {
+		file.Close()
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	return nil
+}
+
+// Write appends the data to the log file, rotating it first if required.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := unix.Flock(int(f.lock.Fd()), unix.LOCK_EX); err != nil {
+		return 0, fmt.Errorf("error locking log file: %v", err)
+	}
+	defer unix.Flock(int(f.lock.Fd()), unix.LOCK_UN)
+
+	// The file may have been rotated by another process.
+	if err := f.reopenIfMoved(); err != nil {
+		return 0, err
+	}
+
+	if f.maxSize > 0 {
+		info, err := f.file.Stat()
+		if err == nil && info.Size() > 0 && info.Size()+int64(len(p)) > f.maxSize {
+			if err := f.rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return f.file.Write(p)
+}
+
+func (f *RotatingFile) reopenIfMoved() error {
+	current, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		return f.open()
+	}
+	if err != nil {
+		return fmt.Errorf("error checking log file: %v", err)
+	}
+	opened, err := f.file.Stat()
+	if err != nil || !os.SameFile(current, opened) {
+		return f.open()
+	}
+	return nil
+}
+
+// rotate shifts the backups, moves the current log file to <path>.1 and opens a
+// new log file.
+func (f *RotatingFile) rotate() error {
+	if f.maxBackups <= 0 {
+		if err := os.Truncate(f.path, 0); err != nil {
+			return fmt.Errorf("error truncating log file: %v", err)
+		}
+		return nil
+	}
+
+	os.Remove(backupPath(f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(f.path, i), backupPath(f.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotating log file: %v", err)
+		}
+	}
+	if err := os.Rename(f.path, backupPath(f.path, 1)); err != nil {
+		return fmt.Errorf("error rotating log file: %v", err)
+	}
+	return f.open()
+}
+
+// Close closes the log file.
+func (f *RotatingFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	f.lock.Close()
+	return f.file.Close()
+}
+
+// applyMode sets the permissions of the opened file to the specified mode if
+// they differ.
+func applyMode(file *os.File, mode os.FileMode) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error checking %v: %v", file.Name(), err)
+	}
+	if info.Mode().Perm() == mode.Perm() {
+		return nil
+	}
+	if err := file.Chmod(mode.Perm()); err != nil {
+		return fmt.Errorf("error setting mode of %v: %v", file.Name(), err)
+	}
+	return nil
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
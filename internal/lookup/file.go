@@ -61,6 +61,13 @@ func WithCount(count int) Option {
 	}
 }
 
+// WithOptional sets whether a pattern without matches is an error.
+func WithOptional(optional bool) Option {
+	return func(f *builder) {
+		f.isOptional = optional
+	}
+}
+
 func WithFilter(assert func(string) error) Option {
 	return func(f *builder) {
 		f.filter = assert
@@ -128,6 +135,12 @@ visit:
 	return filenames, nil
 }
 
+// NewFileLocator creates a locator for files matching a pattern in the
+// specified search paths.
+func NewFileLocator(opts ...Option) Locator {
+	return newFileLocator(opts...)
+}
+
 func newFileLocator(opts ...Option) *file {
 	return newBuilder(opts...).build()
 }
@@ -0,0 +1,81 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package lookup
+
+import (
+	"path/filepath"
+)
+
+// DefaultLibrarySearchPaths are the directories searched for libraries if none
+// are specified.
+var DefaultLibrarySearchPaths = []string{
+	"/usr/local/corex/lib64",
+	"/usr/local/corex/lib",
+	"/usr/lib64",
+	"/usr/lib/x86_64-linux-gnu",
+	"/usr/lib/aarch64-linux-gnu",
+	"/usr/lib",
+	"/lib64",
+	"/lib",
+}
+
+type library struct {
+	file
+}
+
+// NewLibraryLocator creates a locator for libraries in the specified search
+// paths. All matches for a pattern are returned, but a library is only returned
+// once even if it is found in several search paths. Patterns without matches
+// are not an error.
+func NewLibraryLocator(root string, searchPaths ...string) Locator {
+	if len(searchPaths) == 0 {
+		searchPaths = DefaultLibrarySearchPaths
+	}
+	f := newFileLocator(
+		WithRoot(root),
+		WithSearchPaths(searchPaths...),
+		WithOptional(true),
+	)
+
+	l := library{
+		file: *f,
+	}
+
+	return &l
+}
+
+// Locate returns the libraries matching the pattern. Libraries with the same
+// name found in later search paths are skipped.
+func (l library) Locate(pattern string) ([]string, error) {
+	candidates, err := l.file.Locate(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var libraries []string
+	for _, c := range candidates {
+		name := filepath.Base(c)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		libraries = append(libraries, c)
+	}
+	return libraries, nil
+}
@@ -0,0 +1,205 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/pkg/parser"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+type cdiModifier struct {
+	specDirs []string
+	devices  []string
+}
+
+// NewCDIModifier creates a modifier that injects the requested devices and the
+// devices from the CDI annotations as resolved from the CDI specifications on
+// the host. Unqualified requested device names are qualified with the default
+// CDI kind.
+func NewCDIModifier(image image.CUDA) (oci.SpecModifier, error) {
+	annotationDevices, err := image.CDIDevicesFromAnnotations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CDI annotations: %v", err)
+	}
+
+	devices, err := cdiDevicesFromRequest(image, image.Cfg.CDI.DefaultKind)
+	if err != nil {
+		return nil, err
+	}
+	return newCDIModifier(image.Cfg.CDI.SpecDirs, append(annotationDevices, devices...))
+}
+
+// NewCDIAnnotationModifier creates a modifier that injects the devices requested
+// through the CDI annotations. If enabled in the config, fully-qualified CDI device
+// names in the requested devices (e.g. IX_VISIBLE_DEVICES) are also injected. This allows engines without native
+// CDI support to consume the CDI specifications generated by ix-ctk.
+func NewCDIAnnotationModifier(image image.CUDA) (oci.SpecModifier, error) {
+	devices, err := image.CDIDevicesFromAnnotations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CDI annotations: %v", err)
+	}
+
+	if image.Cfg.CDI.AcceptEnvvarDevices {
+		requested, err := cdiDevicesFromRequest(image, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range requested {
+			if parser.IsQualifiedName(d) {
+				devices = append(devices, d)
+			}
+		}
+	}
+	return newCDIModifier(image.Cfg.CDI.SpecDirs, devices)
+}
+
+func newCDIModifier(specDirs []string, devices []string) (oci.SpecModifier, error) {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, d := range devices {
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		unique = append(unique, d)
+	}
+
+	if len(unique) == 0 {
+		log.Printf("No CDI modifier required\n")
+		return nil, nil
+	}
+
+	return cdiModifier{
+		specDirs: specDirs,
+		devices:  unique,
+	}, nil
+}
+
+// cdiDevicesFromRequest returns the CDI device names for the devices requested
+// for the container. Names that are not fully qualified are qualified with the
+// specified kind, if any.
+func cdiDevicesFromRequest(image image.CUDA, kind string) ([]string, error) {
+	visibleDevices, err := image.VisibleDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, d := range visibleDevices.List() {
+		switch d {
+		case "", "void", "none":
+			continue
+		}
+		if kind != "" && !parser.IsQualifiedName(d) {
+			d = kind + "=" + d
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func (m cdiModifier) Modify(spec *specs.Spec) error {
+	registry, err := newCDICache(m.specDirs)
+	if err != nil {
+		return err
+	}
+
+	var existingRules []specs.LinuxDeviceCgroup
+	if spec.Linux != nil && spec.Linux.Resources != nil {
+		existingRules = spec.Linux.Resources.Devices
+	}
+	var existingEnv []string
+	if spec.Process != nil {
+		existingEnv = spec.Process.Env
+	}
+
+	log.Printf("Injecting CDI devices %v\n", m.devices)
+	_, err = registry.InjectDevices(spec, m.devices...)
+	if err != nil {
+		return fmt.Errorf("failed to inject CDI devices: %v", err)
+	}
+
+	// The CDI cache replaces existing devices and mounts, but always appends
+	// environment variables and device cgroup rules. Reconcile the added entries
+	// with the existing ones.
+	if spec.Process != nil && len(spec.Process.Env) > len(existingEnv) {
+		added := spec.Process.Env[len(existingEnv):]
+		spec.Process.Env = existingEnv[:len(existingEnv):len(existingEnv)]
+		for _, e := range added {
+			key, value, _ := strings.Cut(e, "=")
+			setEnv(spec, key, value)
+		}
+	}
+	if spec.Linux != nil && spec.Linux.Resources != nil && len(spec.Linux.Resources.Devices) > len(existingRules) {
+		added := spec.Linux.Resources.Devices[len(existingRules):]
+		spec.Linux.Resources.Devices = existingRules[:len(existingRules):len(existingRules)]
+		for _, rule := range added {
+			allowDevice(spec, rule)
+		}
+	}
+	return nil
+}
+
+// Record records the injected CDI devices.
+func (m cdiModifier) Record(c *state.Container) {
+	c.CDIDevices = append(c.CDIDevices, m.devices...)
+}
+
+// newCDICache creates a CDI cache for the specified spec directories. Errors in
+// individual specs are logged and the remaining specs are still used.
+func newCDICache(specDirs []string) (*cdi.Cache, error) {
+	registry, err := cdi.NewCache(
+		cdi.WithAutoRefresh(false),
+		cdi.WithSpecDirs(specDirs...),
+	)
+	if registry == nil {
+		return nil, fmt.Errorf("failed to create CDI cache: %v", err)
+	}
+	for path, errs := range registry.GetErrors() {
+		for _, e := range errs {
+			log.Warnf("Error in CDI spec %v: %v", path, e)
+		}
+	}
+	return registry, nil
+}
+
+// HasCDIDevices checks whether a CDI specification in the specified directories
+// defines devices of the specified kind.
+func HasCDIDevices(specDirs []string, kind string) bool {
+	registry, err := newCDICache(specDirs)
+	if err != nil {
+		log.Warnf("Unable to read CDI specifications: %v", err)
+		return false
+	}
+	for _, device := range registry.ListDevices() {
+		vendor, class, _ := parser.ParseDevice(device)
+		if vendor+"/"+class == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"path/filepath"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
+	"gitee.com/deep-spark/ix-container-runtime/internal/lookup"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/pkg/ixcdi"
+	"gitee.com/deep-spark/ix-container-runtime/pkg/ixcdi/discover"
+)
+
+const (
+	// driverExecutableSearchPath is searched for driver tools before the PATH.
+	driverExecutableSearchPath = "/usr/local/corex/bin"
+)
+
+var (
+	// driverLibraries are the host libraries injected for each capability.
+	driverLibraries = map[image.DriverCapability][]string{
+		image.DriverCapabilityCompute: {"libcuda.so*", "libixthunk.so*"},
+		image.DriverCapabilityUtility: {"libixml.so*"},
+	}
+	// driverExecutables are the host tools injected for each capability.
+	driverExecutables = map[image.DriverCapability][]string{
+		image.DriverCapabilityUtility: {"ixsmi"},
+	}
+)
+
+type driverModifier struct {
+	libraries   discover.Discover
+	executables discover.Discover
+	ctkPath     string
+}
+
+// NewDriverModifier creates a modifier that bind-mounts the host driver files
+// for the capabilities requested through IX_DRIVER_CAPABILITIES read-only into
+// the container. Driver files are injected if devices are requested, including
+// IX_VISIBLE_DEVICES=none, but not if no device request is made (void).
+func NewDriverModifier(cudaImage image.CUDA) (oci.SpecModifier, error) {
+	capabilities := cudaImage.DriverCapabilities()
+	if len(capabilities) == 0 {
+		log.Printf("No driver modifier required\n")
+		return nil, nil
+	}
+
+	devices, err := cudaImage.VisibleDevices()
+	if err != nil {
+		return nil, err
+	}
+	if devices.List() == nil {
+		log.Printf("No devices requested; ignoring driver capabilities %v", capabilities.List())
+		return nil, nil
+	}
+
+	var libraries, executables []string
+	for _, capability := range capabilities.List() {
+		libraries = append(libraries, driverLibraries[image.DriverCapability(capability)]...)
+		executables = append(executables, driverExecutables[image.DriverCapability(capability)]...)
+	}
+	log.Infof("Injecting driver files for capabilities %v", capabilities.List())
+
+	var librarySearchPaths []string
+	if cudaImage.Cfg.LibraryPath != "" {
+		librarySearchPaths = append(librarySearchPaths, filepath.Dir(cudaImage.Cfg.LibraryPath))
+	}
+	librarySearchPaths = append(librarySearchPaths, lookup.DefaultLibrarySearchPaths...)
+
+	executableLocator := lookup.NewFileLocator(
+		lookup.WithSearchPaths(append([]string{driverExecutableSearchPath}, lookup.GetPaths("")...)...),
+		lookup.WithCount(1),
+		lookup.WithOptional(true),
+	)
+
+	m := driverModifier{
+		libraries:   discover.NewMountsAt(lookup.NewLibraryLocator("", librarySearchPaths...), ixcdi.DriverLibraryDir, libraries),
+		executables: discover.NewMountsAt(executableLocator, ixcdi.DriverExecutableDir, executables),
+		ctkPath:     hookCTKPath(cudaImage.Cfg.CTKPath),
+	}
+	return m, nil
+}
+
+// Modify mounts the located driver files into the driver directory of the
+// container and prepends the directories to LD_LIBRARY_PATH and PATH. The
+// library directory is also added to the linker cache of the container and the
+// unversioned links to the versioned libraries are created.
+func (m driverModifier) Modify(spec *specs.Spec) error {
+	libraries, err := m.libraries.Mounts()
+	if err != nil {
+		return err
+	}
+	executables, err := m.executables.Mounts()
+	if err != nil {
+		return err
+	}
+
+	for _, l := range libraries {
+		addMount(spec, driverMount(l))
+	}
+	for _, e := range executables {
+		addMount(spec, driverMount(e))
+	}
+
+	if len(libraries) > 0 {
+		prependPathEnv(spec, ldPathEnv, ixcdi.DriverLibraryDir)
+		addCreateSymlinksHook(spec, m.ctkPath, discover.LibrarySymlinks(libraries)...)
+		addLDCacheUpdateHook(spec, m.ctkPath, ixcdi.DriverLibraryDir)
+	}
+	if len(executables) > 0 {
+		prependPathEnv(spec, pathEnv, ixcdi.DriverExecutableDir)
+	}
+	return nil
+}
+
+func driverMount(m discover.Mount) specs.Mount {
+	return specs.Mount{
+		Source:      m.HostPath,
+		Destination: m.Path,
+		Type:        "bind",
+		Options:     m.Options,
+	}
+}
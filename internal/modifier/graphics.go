@@ -19,24 +19,29 @@ package modifier
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	"gitee.com/deep-spark/go-ixml/pkg/ixml"
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
 	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
 	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
+	"tags.cncf.io/container-device-interface/pkg/parser"
 )
 
 var (
-	visibleDevicesEnvvar = "IX_VISIBLE_DEVICES"
-	deviceName           = "iluvatar"
-	devicePath           = "/dev"
+	deviceName = "iluvatar"
+	devicePath = "/dev"
 
 	wildcardDevice = "a"
 	blockDevice    = "b"
@@ -46,8 +51,27 @@ var (
 	ErrNotADevice = errors.New("not a device node")
 )
 
+// deviceNodePattern matches the paths of the device nodes of the devices.
+var deviceNodePattern = regexp.MustCompile(`^/dev/` + deviceName + `[0-9]+$`)
+
+const (
+	// deviceMapEnvvar exposes the host index of each device to the container if
+	// the devices are numbered contiguously, e.g. 0:2,1:5.
+	deviceMapEnvvar = "IX_DEVICE_MAP"
+
+	// The following variables expose the identities of the injected devices to
+	// the container as comma-separated lists in the order of the devices in the
+	// container.
+	deviceUUIDsEnvvar     = "IX_DEVICE_UUIDS"
+	devicePciBusIDsEnvvar = "IX_DEVICE_PCI_BUS_IDS"
+	deviceNamesEnvvar     = "IX_DEVICE_NAMES"
+)
+
 type graphicsModifier struct {
-	addDevice []specs.LinuxDevice
+	devices []IndexDevice
+	// contiguous numbers the device nodes in the container from 0 in the order
+	// of the devices instead of using the host device nodes.
+	contiguous bool
 }
 
 type IndexDevice struct {
@@ -57,35 +81,126 @@ type IndexDevice struct {
 }
 
 func (g graphicsModifier) Modify(spec *specs.Spec) error {
-	var tmpPtr *specs.LinuxDevice
-	if g.addDevice == nil {
-		return nil
-	}
-	if len(g.addDevice) == 0 {
-		return nil
+	if g.contiguous {
+		// Device nodes already in the spec (e.g. all host devices of a
+		// privileged container) would shadow the renumbered devices.
+		removeDevices(spec, func(d specs.LinuxDevice) bool {
+			return deviceNodePattern.MatchString(d.Path)
+		})
 	}
 
-	for _, d := range g.addDevice {
-		spec.Linux.Devices = append(spec.Linux.Devices, d)
-		tmpPtr = new(specs.LinuxDevice)
-		*tmpPtr = d
-		newDeviceCgroup := specs.LinuxDeviceCgroup{
+	var deviceMap []string
+	for i, dev := range g.devices {
+		d := g.containerDevice(i, dev)
+		if g.contiguous {
+			deviceMap = append(deviceMap, fmt.Sprintf("%d:%d", i, dev.Index))
+		}
+		major, minor := d.Major, d.Minor
+		addDevice(spec, d)
+		allowDevice(spec, specs.LinuxDeviceCgroup{
 			Allow:  true,
-			Type:   tmpPtr.Type,
-			Major:  &tmpPtr.Major,
-			Minor:  &tmpPtr.Minor,
+			Type:   d.Type,
+			Major:  &major,
+			Minor:  &minor,
 			Access: "rwm",
-		}
-		spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, newDeviceCgroup)
+		})
+	}
+	if len(deviceMap) > 0 {
+		setEnv(spec, deviceMapEnvvar, strings.Join(deviceMap, ","))
+	}
+	if len(g.devices) > 0 {
+		g.setDeviceEnv(spec)
 	}
 	return nil
 }
 
-func searchDevice() map[int]specs.LinuxDevice {
+// setDeviceEnv exposes the UUIDs, PCI bus IDs and names of the injected devices
+// to the container. IX_VISIBLE_DEVICES is rewritten to the indices of the
+// device nodes in the container: the host indices, or 0 to N-1 if the devices
+// are numbered contiguously. In the latter case the original request is kept in
+// IX_REQUESTED_DEVICES. Identities that cannot be queried are left empty.
+func (g graphicsModifier) setDeviceEnv(spec *specs.Spec) {
+	if g.contiguous {
+		if _, ok := getEnv(spec, image.EnvVarIXRequestedDevices); !ok {
+			request, ok := getEnv(spec, image.EnvVarIXVisibleDevices)
+			if !ok {
+				// An unset IX_VISIBLE_DEVICES selects all devices.
+				request = "all"
+			}
+			setEnv(spec, image.EnvVarIXRequestedDevices, request)
+		}
+	}
+
+	var indices, uuids, busIDs, names []string
+	for i, dev := range g.devices {
+		index := strconv.FormatUint(uint64(dev.Index), 10)
+		if g.contiguous {
+			index = strconv.Itoa(i)
+		}
+		indices = append(indices, index)
+
+		uuid, ret := dev.Device.GetUUID()
+		if ret != ixml.SUCCESS {
+			log.Warnf("Unable to get UUID of device %v: %v", dev.Index, ret)
+			uuid = ""
+		}
+		uuids = append(uuids, uuid)
+
+		busID, err := pciBusID(dev.Device)
+		if err != nil {
+			log.Warnf("Unable to get PCI bus ID of device %v: %v", dev.Index, err)
+		}
+		busIDs = append(busIDs, busID)
+
+		name, ret := dev.Device.GetName()
+		if ret != ixml.SUCCESS {
+			log.Warnf("Unable to get name of device %v: %v", dev.Index, ret)
+			name = ""
+		}
+		names = append(names, name)
+	}
+
+	setEnv(spec, image.EnvVarIXVisibleDevices, strings.Join(indices, ","))
+	setEnv(spec, deviceUUIDsEnvvar, strings.Join(uuids, ","))
+	setEnv(spec, devicePciBusIDsEnvvar, strings.Join(busIDs, ","))
+	setEnv(spec, deviceNamesEnvvar, strings.Join(names, ","))
+}
+
+// containerDevice returns the device node for the device at the specified
+// position as it appears in the container. The host major and minor numbers
+// are kept if the device is renamed.
+func (g graphicsModifier) containerDevice(i int, dev IndexDevice) specs.LinuxDevice {
+	if !g.contiguous {
+		return dev.LinuxDevice
+	}
+	return buildMountDevice(i, dev.LinuxDevice)
+}
+
+// Record records the injected devices.
+func (g graphicsModifier) Record(c *state.Container) {
+	for i, dev := range g.devices {
+		uuid, ret := dev.Device.GetUUID()
+		if ret != ixml.SUCCESS {
+			log.Warnf("Unable to get UUID of device %v: %v", dev.Index, ret)
+			uuid = ""
+		}
+		device := state.Device{
+			Index: dev.Index,
+			UUID:  uuid,
+			Path:  dev.Path,
+		}
+		if d := g.containerDevice(i, dev); d.Path != dev.Path {
+			device.ContainerPath = d.Path
+		}
+		c.Devices = append(c.Devices, device)
+	}
+}
+
+func searchDevice() (map[int]specs.LinuxDevice, error) {
 	ret := make(map[int]specs.LinuxDevice)
 	libRegEx, e := regexp.Compile(deviceName + "[0-9]")
 	if e != nil {
-		log.Fatal(e)
+		return nil, e
 	}
 	e = filepath.Walk(devicePath, func(path string, info os.FileInfo, err error) error {
 		if err == nil && libRegEx.MatchString(info.Name()) {
@@ -130,69 +245,143 @@ func searchDevice() map[int]specs.LinuxDevice {
 	})
 
 	if e != nil {
-		log.Printf("error for walk:%v\n", e)
-		return nil
+		return nil, fmt.Errorf("failed to search for device nodes in %v: %v", devicePath, e)
 	}
 
-	return ret
+	return ret, nil
 }
 
-func generate_dev_from_string(devmap map[uint]IndexDevice, val string) *specs.LinuxDevice {
-	var ret specs.LinuxDevice
-	i, err := strconv.Atoi(val)
-	if err != nil {
-		log.Printf("Can't transfer %v to int type", val)
-		return nil
+// pciBusIDPattern matches PCI bus IDs of the form [domain:]bus:device.function
+var pciBusIDPattern = regexp.MustCompile(`^(?:([0-9a-fA-F]{1,8}):)?([0-9a-fA-F]{1,2}):([0-9a-fA-F]{1,2})\.([0-7])$`)
+
+// pciAddress is the parsed representation of a PCI bus ID.
+type pciAddress struct {
+	domain, bus, device, function uint64
+}
+
+// parsePciBusID parses a PCI bus ID such as 00000000:8A:00.0 or 8a:00.0.
+func parsePciBusID(id string) (pciAddress, bool) {
+	m := pciBusIDPattern.FindStringSubmatch(strings.TrimSpace(id))
+	if m == nil {
+		return pciAddress{}, false
+	}
+	var addr pciAddress
+	if m[1] != "" {
+		addr.domain, _ = strconv.ParseUint(m[1], 16, 32)
+	}
+	addr.bus, _ = strconv.ParseUint(m[2], 16, 8)
+	addr.device, _ = strconv.ParseUint(m[3], 16, 8)
+	addr.function, _ = strconv.ParseUint(m[4], 16, 8)
+	return addr, true
+}
+
+// pciBusID returns the PCI bus ID of the specified device.
+func pciBusID(d ixml.Device) (string, error) {
+	info, ret := d.GetPciInfo()
+	if ret != ixml.SUCCESS {
+		return "", fmt.Errorf("failed to get PCI info: %v", ret)
+	}
+	var id []byte
+	for _, c := range info.BusId {
+		if c == 0 {
+			break
+		}
+		id = append(id, byte(c))
+	}
+	return string(id), nil
+}
+
+// resolveDevice returns the device that the specified ID refers to. The ID is
+// interpreted as a device index, a PCI bus ID or a device UUID, in that order.
+func resolveDevice(devmap map[uint]IndexDevice, val string) (IndexDevice, error) {
+	if i, err := strconv.Atoi(val); err == nil {
+		dev, ok := devmap[uint(i)]
+		if i < 0 || !ok {
+			return IndexDevice{}, fmt.Errorf("no device with index %v", val)
+		}
+		return dev, nil
+	}
+
+	if addr, ok := parsePciBusID(val); ok {
+		for _, dev := range devmap {
+			id, err := pciBusID(dev.Device)
+			if err != nil {
+				return IndexDevice{}, fmt.Errorf("unable to resolve PCI bus ID %v for device %v: %v", val, dev.Index, err)
+			}
+			if devAddr, ok := parsePciBusID(id); ok && devAddr == addr {
+				return dev, nil
+			}
+		}
+		return IndexDevice{}, fmt.Errorf("no device with PCI bus ID %v", val)
 	}
 
-	dev, ok := devmap[uint(i)]
+	device, ret := ixml.GetHandleByUUID(val)
+	if ret != ixml.SUCCESS {
+		return IndexDevice{}, fmt.Errorf("no device with index, PCI bus ID or UUID %v: %v", val, ret)
+	}
+	index, ret := device.GetIndex()
+	if ret != ixml.SUCCESS {
+		return IndexDevice{}, fmt.Errorf("unable to get index of device %v: %v", val, ret)
+	}
+	dev, ok := devmap[uint(index)]
 	if !ok {
-		log.Printf("Wrong parameter: %v", val)
-		return nil
+		return IndexDevice{}, fmt.Errorf("no device with UUID %v", val)
+	}
+	return dev, nil
+}
+
+func generate_dev_from_string(devmap map[uint]IndexDevice, val string) (*IndexDevice, error) {
+	if parser.IsQualifiedName(val) {
+		log.Debugf("Skipping CDI device %v", val)
+		return nil, nil
+	}
+
+	dev, err := resolveDevice(devmap, val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device request: %v", err)
 	}
-	ret = dev.LinuxDevice
 	strIdx := strconv.Itoa(int(dev.Minor))
-	ret.Path = devicePath + "/" + deviceName + strIdx
-	return &ret
+	dev.Path = devicePath + "/" + deviceName + strIdx
+	return &dev, nil
 }
 
-func getdevice(devmap map[uint]IndexDevice, cudaImage image.CUDA) []specs.LinuxDevice {
-	var ret []specs.LinuxDevice
-	devices := cudaImage.DevicesFromEnvvars(visibleDevicesEnvvar)
+func getdevice(devmap map[uint]IndexDevice, devices image.VisibleDevices) ([]IndexDevice, error) {
+	var ret []IndexDevice
 	if len(devices.List()) == 0 {
-		return nil
+		return nil, nil
 	} else if len(devices.List()) == 1 {
 		val := devices.List()[0]
 		switch val {
 		case "all":
 			for _, dev := range devmap {
-				ret = append(ret, dev.LinuxDevice)
-			}
-			return ret
-		case "void":
-			return nil
-		case "none":
-			return nil
-		default:
-			dev := generate_dev_from_string(devmap, val)
-			if dev != nil {
-				ret = append(ret, *dev)
-				return ret
-			} else {
-				return nil
+				ret = append(ret, dev)
 			}
+			sort.Slice(ret, func(i, j int) bool {
+				return ret[i].Index < ret[j].Index
+			})
+			return ret, nil
+		case "", "void", "none":
+			return nil, nil
 		}
 	}
 
+	seen := make(map[uint]bool)
 	for _, v := range devices.List() {
-		dev := generate_dev_from_string(devmap, v)
-		if dev != nil {
-			ret = append(ret, *dev)
+		dev, err := generate_dev_from_string(devmap, v)
+		if err != nil {
+			return nil, err
+		}
+		if dev == nil || seen[dev.Index] {
+			continue
 		}
+		seen[dev.Index] = true
+		ret = append(ret, *dev)
 	}
-	return ret
+	return ret, nil
 }
 
+// buildMountDevice returns the device node for the device at the specified
+// container index, keeping the host major and minor numbers.
 func buildMountDevice(index int, dev specs.LinuxDevice) specs.LinuxDevice {
 	devIdx := strconv.Itoa(index)
 	mountPath := devicePath + "/" + deviceName + devIdx
@@ -206,12 +395,15 @@ func buildMountDevice(index int, dev specs.LinuxDevice) specs.LinuxDevice {
 	}
 }
 
-// requiresGraphicsModifier determines whether a graphics modifier is required.
-func buildMap(librarypath string) map[uint]IndexDevice {
+// buildMap returns the devices reported by ixml, keyed by their index.
+func buildMap(librarypath string) (map[uint]IndexDevice, error) {
 	IndexMap := make(map[uint]IndexDevice)
 	var ret ixml.Return
 
-	devs := searchDevice()
+	devs, err := searchDevice()
+	if err != nil {
+		return nil, err
+	}
 
 	if librarypath != "" {
 		ret = ixml.AbsInit(librarypath)
@@ -219,14 +411,11 @@ func buildMap(librarypath string) map[uint]IndexDevice {
 		ret = ixml.Init()
 	}
 	if ret != ixml.SUCCESS {
-		log.Printf("Unable to initialize IXML:%v\n", ret)
-		log.Printf("librarypath:%v", librarypath)
-		return nil
+		return nil, fmt.Errorf("unable to initialize IXML from %q: %v", librarypath, ret)
 	}
 	count, ret := ixml.DeviceGetCount()
 	if ret != ixml.SUCCESS {
-		log.Printf("failed to get count:%v\n", ret)
-		return nil
+		return nil, fmt.Errorf("failed to get device count: %v", ret)
 	}
 
 	log.Printf("count: %d\n", count)
@@ -236,12 +425,12 @@ func buildMap(librarypath string) map[uint]IndexDevice {
 		ret = ixml.DeviceGetHandleByIndex(i, &device)
 
 		if ret != ixml.SUCCESS {
-			log.Fatalf("Unable to get device at index %d: %v", i, ret)
+			return nil, fmt.Errorf("unable to get device at index %d: %v", i, ret)
 		}
 
 		MinorID, ret := device.GetMinorNumber()
 		if ret != ixml.SUCCESS {
-			log.Fatalf("Unable to get id of device at index %v: %v", MinorID, ret)
+			return nil, fmt.Errorf("unable to get minor number of device at index %d: %v", i, ret)
 		}
 		IndexMap[i] = IndexDevice{
 			Index:       i,
@@ -250,19 +439,49 @@ func buildMap(librarypath string) map[uint]IndexDevice {
 		}
 	}
 
-	return IndexMap
+	return IndexMap, nil
 }
 
-func NewGraphicsModifier(image image.CUDA) oci.SpecModifier {
-	devMap := buildMap(image.Cfg.LibraryPath)
-	if devMap == nil {
+// NewGraphicsModifier creates a modifier that injects the requested devices
+// discovered through ixml. ixml is only queried if devices are requested.
+func NewGraphicsModifier(image image.CUDA) (oci.SpecModifier, error) {
+	visibleDevices, err := image.VisibleDevices()
+	if err != nil {
+		return nil, err
+	}
+	if !requiresGraphicsModifier(visibleDevices) {
 		log.Printf("No graphics modifier required\n")
-		return nil
+		return nil, nil
+	}
+
+	devMap, err := buildMap(image.Cfg.LibraryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := getdevice(devMap, visibleDevices)
+	if err != nil {
+		return nil, err
 	}
 
 	ret := graphicsModifier{
-		addDevice: getdevice(devMap, image),
+		devices:    devices,
+		contiguous: image.Cfg.DeviceNumbering == config.DeviceNumberingContiguous,
 	}
 
-	return ret
+	return ret, nil
+}
+
+// requiresGraphicsModifier determines whether a graphics modifier is required.
+func requiresGraphicsModifier(devices image.VisibleDevices) bool {
+	for _, d := range devices.List() {
+		switch d {
+		case "", "void", "none":
+			continue
+		}
+		if !parser.IsQualifiedName(d) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,98 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"os"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/pkg/ixcdi/discover"
+)
+
+// hookCTKPath returns the path of ix-ctk to use in the hooks added to the
+// container. An empty path, which disables the hooks, is returned if ix-ctk is
+// not configured or does not exist.
+func hookCTKPath(ctkPath string) string {
+	if ctkPath == "" {
+		return ""
+	}
+	if _, err := os.Stat(ctkPath); err != nil {
+		log.Warnf("Not adding ix-ctk hooks: %v", err)
+		return ""
+	}
+	return ctkPath
+}
+
+// addLDCacheUpdateHook adds the folders to the update-ldcache hook of the
+// container so that the injected libraries are found without LD_LIBRARY_PATH.
+func addLDCacheUpdateHook(spec *specs.Spec, ctkPath string, folders ...string) {
+	addIXCTKHook(spec, ctkPath, discover.UpdateLDCacheHook, "--folder", folders...)
+}
+
+// addCreateSymlinksHook adds the links, specified as target::link, to the
+// create-symlinks hook of the container.
+func addCreateSymlinksHook(spec *specs.Spec, ctkPath string, links ...string) {
+	addIXCTKHook(spec, ctkPath, discover.CreateSymlinksHook, "--link", links...)
+}
+
+// addIXCTKHook adds the ix-ctk hook subcommand with the specified flag values to
+// the createContainer hooks of the spec. If the spec already contains the hook,
+// the values that are missing are appended to it instead so that the hook runs
+// once for all modifiers.
+func addIXCTKHook(spec *specs.Spec, ctkPath string, hookName string, flag string, values ...string) {
+	if ctkPath == "" || len(values) == 0 {
+		return
+	}
+	if spec.Hooks == nil {
+		spec.Hooks = &specs.Hooks{}
+	}
+
+	for i, h := range spec.Hooks.CreateContainer {
+		if h.Path != ctkPath || len(h.Args) < 3 || h.Args[1] != "hook" || h.Args[2] != hookName {
+			continue
+		}
+		existing := make(map[string]bool)
+		for j := 3; j < len(h.Args)-1; j++ {
+			if h.Args[j] == flag {
+				existing[h.Args[j+1]] = true
+			}
+		}
+		for _, v := range values {
+			if existing[v] {
+				continue
+			}
+			existing[v] = true
+			h.Args = append(h.Args, flag, v)
+		}
+		spec.Hooks.CreateContainer[i] = h
+		return
+	}
+
+	var args []string
+	for _, v := range values {
+		args = append(args, flag, v)
+	}
+	hook := discover.CreateIXCTKHook(ctkPath, hookName, args...)
+	log.Infof("Adding %v hook %v", hook.Lifecycle, hook.Args)
+	spec.Hooks.CreateContainer = append(spec.Hooks.CreateContainer, specs.Hook{
+		Path: hook.Path,
+		Args: hook.Args,
+	})
+}
@@ -19,9 +19,16 @@ package modifier
 
 import (
 	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// Recorder is implemented by modifiers that can record the resources they
+// injected into a container. Record is called after Modify.
+type Recorder interface {
+	Record(*state.Container)
+}
+
 type list struct {
 	modifiers []oci.SpecModifier
 }
@@ -53,3 +60,12 @@ func (m list) Modify(spec *specs.Spec) error {
 
 	return nil
 }
+
+// Record records the resources injected by the modifiers in the list.
+func (m list) Record(c *state.Container) {
+	for _, mm := range m.modifiers {
+		if r, ok := mm.(Recorder); ok {
+			r.Record(c)
+		}
+	}
+}
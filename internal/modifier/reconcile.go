@@ -0,0 +1,172 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package modifier
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// The helpers in this file add entries to an OCI spec while reconciling them
+// with the existing entries, so that applying a modifier more than once, or to a
+// spec that already requests the same resources, does not add duplicates.
+
+// addDevice adds the device to the spec unless a device with the same path or
+// the same type and major/minor numbers is already present.
+func addDevice(spec *specs.Spec, device specs.LinuxDevice) {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	for _, d := range spec.Linux.Devices {
+		if filepath.Clean(d.Path) == filepath.Clean(device.Path) {
+			log.Debugf("Device %v already present", device.Path)
+			return
+		}
+		if d.Type == device.Type && d.Major == device.Major && d.Minor == device.Minor {
+			log.Debugf("Device %v already present as %v", device.Path, d.Path)
+			return
+		}
+	}
+	spec.Linux.Devices = append(spec.Linux.Devices, device)
+}
+
+// removeDevices removes the devices matching the specified function from the
+// spec. Device cgroup rules are not changed.
+func removeDevices(spec *specs.Spec, match func(specs.LinuxDevice) bool) {
+	if spec.Linux == nil {
+		return
+	}
+	var devices []specs.LinuxDevice
+	for _, d := range spec.Linux.Devices {
+		if match(d) {
+			log.Debugf("Removing device %v", d.Path)
+			continue
+		}
+		devices = append(devices, d)
+	}
+	spec.Linux.Devices = devices
+}
+
+// allowDevice adds an allow rule for the device to the device cgroup of the spec
+// unless an equivalent allow rule is already in effect, i.e. is not followed by
+// a deny rule.
+func allowDevice(spec *specs.Spec, rule specs.LinuxDeviceCgroup) {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &specs.LinuxResources{}
+	}
+	rules := spec.Linux.Resources.Devices
+	for i := len(rules) - 1; i >= 0; i-- {
+		if !rules[i].Allow {
+			break
+		}
+		if sameDeviceRule(rules[i], rule) {
+			return
+		}
+	}
+	spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, rule)
+}
+
+func sameDeviceRule(a specs.LinuxDeviceCgroup, b specs.LinuxDeviceCgroup) bool {
+	return a.Allow == b.Allow && a.Type == b.Type && a.Access == b.Access &&
+		sameID(a.Major, b.Major) && sameID(a.Minor, b.Minor)
+}
+
+func sameID(a *int64, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// addMount adds the mount to the spec unless a mount with the same destination
+// is already present. An existing mount takes precedence.
+func addMount(spec *specs.Spec, mount specs.Mount) {
+	for _, m := range spec.Mounts {
+		if filepath.Clean(m.Destination) == filepath.Clean(mount.Destination) {
+			if m.Source != mount.Source {
+				log.Warnf("Not mounting %v to %v: destination already mounted from %v", mount.Source, mount.Destination, m.Source)
+			}
+			return
+		}
+	}
+	spec.Mounts = append(spec.Mounts, mount)
+}
+
+// getEnv returns the value of the environment variable in the spec.
+func getEnv(spec *specs.Spec, key string) (string, bool) {
+	if spec.Process == nil {
+		return "", false
+	}
+	for _, e := range spec.Process.Env {
+		k, v, _ := strings.Cut(e, "=")
+		if k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// setEnv sets the environment variable in the spec, replacing all existing
+// values for the key.
+func setEnv(spec *specs.Spec, key string, value string) {
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	var env []string
+	for _, e := range spec.Process.Env {
+		if k, _, _ := strings.Cut(e, "="); k == key {
+			continue
+		}
+		env = append(env, e)
+	}
+	spec.Process.Env = append(env, key+"="+value)
+}
+
+// prependPathEnv prepends the entries to the list of paths in the environment
+// variable (e.g. PATH). Entries that are already in the list are not added again.
+func prependPathEnv(spec *specs.Spec, key string, entries ...string) {
+	value, _ := getEnv(spec, key)
+
+	existing := make(map[string]bool)
+	var current []string
+	if value != "" {
+		current = strings.Split(value, ":")
+	}
+	for _, p := range current {
+		existing[p] = true
+	}
+
+	var added []string
+	for _, p := range entries {
+		if existing[p] {
+			continue
+		}
+		existing[p] = true
+		added = append(added, p)
+	}
+	if len(added) == 0 {
+		return
+	}
+	setEnv(spec, key, strings.Join(append(added, current...), ":"))
+}
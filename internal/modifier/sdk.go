@@ -25,6 +25,7 @@ import (
 	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
 	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
 	pb "gitee.com/deep-spark/ix-container-runtime/internal/sdk"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -37,6 +38,13 @@ type sdkModifier struct {
 	Cancel context.CancelFunc
 
 	Change image.VisibleSdk
+
+	// destination is the host path of the SDK cache mounted by Modify.
+	destination string
+	// ctkPath is the path of ix-ctk used in the hook that updates the linker cache.
+	ctkPath string
+	// queryOnly prevents a missing SDK cache from being prepared.
+	queryOnly bool
 }
 
 const (
@@ -77,18 +85,8 @@ func (c *sdkModifier) PrepareCache(imageName string) (bool, string, error) {
 	}
 }
 
-func (s sdkModifier) Modify(spec *specs.Spec) error {
+func (s *sdkModifier) Modify(spec *specs.Spec) error {
 	log.Printf("entry modfiy\n")
-	var destination_corex_dir string
-
-	pathVal := s.Change.Path()
-	pathVal = fmt.Sprintf("%v=%v:%v", pathEnv, pathAdded, pathVal)
-	ldpathVal := s.Change.LdPath()
-	if ldpathVal == "" {
-		ldpathVal = fmt.Sprintf("%v=%v", ldPathEnv, ldPathAdded)
-	} else {
-		ldpathVal = fmt.Sprintf("%v=%v:%v", ldPathEnv, ldPathAdded, ldpathVal)
-	}
 	//var needPull bool
 	image := s.Change.Name()
 	if image == "" {
@@ -96,15 +94,16 @@ func (s sdkModifier) Modify(spec *specs.Spec) error {
 	}
 	destination, _, imageType, err := s.QueryCache(image)
 	if err != nil {
-		log.Printf("query cache failed %v", image)
-		return err
+		return fmt.Errorf("failed to query SDK cache for %v: %v", image, err)
 	}
 
+	if destination == "" && s.queryOnly {
+		return fmt.Errorf("SDK cache for %v does not exist and is not prepared in query-only mode", image)
+	}
 	if destination == "" {
 		_, _, err = s.PrepareCache(image)
 		if err != nil {
-			log.Printf("PrepareCache failed %v", image)
-			return err
+			return fmt.Errorf("failed to prepare SDK cache for %v: %v", image, err)
 		} else {
 			log.Printf("Cache not exists, call prepare to pull image %v", image)
 			return fmt.Errorf("Cache not exists, call prepare to pull image %v", image)
@@ -114,11 +113,9 @@ func (s sdkModifier) Modify(spec *specs.Spec) error {
 		return fmt.Errorf("Image type is not sdk, real type:%v\n", imageType)
 	}
 
-	destination_corex_dir = destination
-
-	spec.Mounts = append(spec.Mounts,
+	addMount(spec,
 		specs.Mount{Destination: defaultDestination,
-			Source: destination_corex_dir,
+			Source: destination,
 			Type:   "linux",
 			Options: []string{
 				"ro",
@@ -126,14 +123,41 @@ func (s sdkModifier) Modify(spec *specs.Spec) error {
 				"nodev",
 				"bind"}})
 
-	log.Printf("---> pathval :%v  ldpathval:%v\n", pathVal, ldpathVal)
-	spec.Process.Env = append(spec.Process.Env, pathVal)
-	spec.Process.Env = append(spec.Process.Env, ldpathVal)
+	s.destination = destination
+	prependPathEnv(spec, pathEnv, pathAdded)
+	prependPathEnv(spec, ldPathEnv, ldPathAdded)
+	addLDCacheUpdateHook(spec, hookCTKPath(s.ctkPath), ldPathAdded)
+	log.Printf("---> pathval :%v  ldpathval:%v\n", envString(spec, pathEnv), envString(spec, ldPathEnv))
 
 	return nil
 }
 
-func NewSdkModifier(ig image.CUDA) oci.SpecModifier {
+// Record records the requested SDK image and the mounted SDK cache.
+func (s *sdkModifier) Record(c *state.Container) {
+	if s.Change.Name() == "" {
+		return
+	}
+	c.SdkImage = s.Change.Name()
+	c.SdkDestination = s.destination
+}
+
+func envString(spec *specs.Spec, key string) string {
+	value, _ := getEnv(spec, key)
+	return value
+}
+
+func NewSdkModifier(ig image.CUDA) (oci.SpecModifier, error) {
+	return newSdkModifier(ig, false)
+}
+
+// NewQueryOnlySdkModifier creates an SDK modifier that only queries the SDK
+// manager for an existing cache and never prepares a missing one. This allows
+// the modifications to be simulated without pulling SDK images on the host.
+func NewQueryOnlySdkModifier(ig image.CUDA) (oci.SpecModifier, error) {
+	return newSdkModifier(ig, true)
+}
+
+func newSdkModifier(ig image.CUDA, queryOnly bool) (oci.SpecModifier, error) {
 	var err error
 	ret := sdkModifier{}
 
@@ -141,12 +165,13 @@ func NewSdkModifier(ig image.CUDA) oci.SpecModifier {
 	log.Printf("connect address:%v", connectPath)
 	ret.conn, err = grpc.NewClient(connectPath, grpc.WithInsecure())
 	if err != nil {
-		log.Printf("open sdk local failed\n")
-		return nil
+		return nil, fmt.Errorf("failed to create client for SDK manager at %v: %v", connectPath, err)
 	}
 	ret.client = pb.NewSdkServiceClient(ret.conn)
 	ret.ctx, ret.Cancel = context.WithTimeout(context.Background(), time.Second)
 	ret.Change = ig.SdkFromEnvvars(visibleSdkEnvvar, pathEnv, ldPathEnv)
+	ret.ctkPath = ig.Cfg.CTKPath
+	ret.queryOnly = queryOnly
 
-	return ret
+	return &ret, nil
 }
@@ -20,6 +20,7 @@ package oci
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -93,24 +94,236 @@ func IsBundleFlag(arg string) bool {
 	return trimmed == "b" || trimmed == "bundle"
 }
 
-// HasCreateSubcommand checks the supplied arguments for a 'create' subcommand
-func HasCreateSubcommand(args []string) bool {
-	var previousWasBundle bool
-	for _, a := range args {
-		// We check for '--bundle create' explicitly to ensure that we
-		// don't inadvertently trigger a modification if the bundle directory
-		// is specified as `create`
-		if !previousWasBundle && IsBundleFlag(a) {
-			previousWasBundle = true
+// globalValueFlags lists the global runc flags that consume the following
+// argument as their value when not specified as --flag=value. The bundle flag is
+// included so that a bundle named like a subcommand (e.g. --bundle create) is
+// not mistaken for the subcommand.
+var globalValueFlags = map[string]bool{
+	"root":       true,
+	"log":        true,
+	"log-format": true,
+	"criu":       true,
+	"rootless":   true,
+	"b":          true,
+	"bundle":     true,
+}
+
+// subcommandValueFlags lists the flags of each runc subcommand that consume the
+// following argument as their value when not specified as --flag=value.
+var subcommandValueFlags = map[string]map[string]bool{
+	"create": flagSet("b", "bundle", "console-socket", "pid-file", "preserve-fds"),
+	"run":    flagSet("b", "bundle", "console-socket", "pid-file", "preserve-fds"),
+	"exec": flagSet("console-socket", "pidfd-socket", "cwd", "e", "env", "u", "user",
+		"g", "additional-gids", "p", "process", "pid-file", "process-label", "apparmor",
+		"c", "cap", "preserve-fds", "cgroup"),
+	"update": flagSet("r", "resources", "blkio-weight", "cpu-period", "cpu-quota",
+		"cpu-share", "cpu-rt-period", "cpu-rt-runtime", "cpuset-cpus", "cpuset-mems",
+		"cpu-idle", "kernel-memory", "kernel-memory-tcp", "m", "memory",
+		"memory-reservation", "memory-swap", "pids-limit", "l3-cache-schema",
+		"mem-bw-schema"),
+	"ps":   flagSet("f", "format"),
+	"list": flagSet("f", "format"),
+	"checkpoint": flagSet("image-path", "work-path", "parent-path", "page-server",
+		"manage-cgroups-mode", "empty-ns", "status-fd"),
+	"restore": flagSet("console-socket", "image-path", "work-path", "b", "bundle",
+		"pid-file", "page-server", "manage-cgroups-mode", "empty-ns", "lsm-profile",
+		"lsm-mount-context"),
+	"events": flagSet("interval"),
+	"spec":   flagSet("b", "bundle"),
+}
+
+func flagSet(names ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// isValueFlag checks whether the flag with the specified name consumes the
+// following argument as its value. An empty subcommand refers to the global
+// flags.
+func isValueFlag(subcommand string, name string) bool {
+	if subcommand == "" {
+		return globalValueFlags[name]
+	}
+	return subcommandValueFlags[subcommand][name]
+}
+
+// positionalArgs returns the arguments that are not flags or flag values. The
+// first positional argument is the subcommand, whose flags are then used. As
+// for runc, the arguments following the container ID of exec are the command
+// to run and are not parsed.
+func positionalArgs(args []string) []string {
+	var positional []string
+	var subcommand string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			positional = append(positional, a)
+			if subcommand == "" {
+				subcommand = a
+			} else if subcommand == "exec" {
+				positional = append(positional, args[i+1:]...)
+				break
+			}
 			continue
 		}
-
-		if !previousWasBundle && a == "create" {
-			return true
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
 		}
+		if isValueFlag(subcommand, name) {
+			i++
+		}
+	}
+	return positional
+}
+
+// GetSubcommand returns the runc subcommand from the supplied arguments.
+// The first element of args is expected to be the executable.
+func GetSubcommand(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	positional := positionalArgs(args[1:])
+	if len(positional) == 0 {
+		return ""
+	}
+	return positional[0]
+}
 
-		previousWasBundle = false
+// GetContainerID returns the container ID from the supplied arguments. This is
+// the first positional argument following the subcommand.
+func GetContainerID(args []string) string {
+	if len(args) < 2 {
+		return ""
 	}
+	positional := positionalArgs(args[1:])
+	if len(positional) < 2 {
+		return ""
+	}
+	return positional[1]
+}
+
+// bundleSubcommands lists the runc subcommands that create a container from the
+// OCI spec in a bundle.
+var bundleSubcommands = map[string]bool{
+	"create":  true,
+	"run":     true,
+	"restore": true,
+}
 
+// HasBundleSubcommand checks whether the supplied arguments specify a subcommand
+// that creates a container from the OCI spec in a bundle (create, run or restore).
+// The first element of args is expected to be the executable.
+func HasBundleSubcommand(args []string) bool {
+	return bundleSubcommands[GetSubcommand(args)]
+}
+
+// GetGlobalFlags returns the global flags, including their values, preceding
+// the subcommand. The first element of args is expected to be the executable.
+func GetGlobalFlags(args []string) []string {
+	var flags []string
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || !strings.HasPrefix(a, "-") || a == "-" {
+			break
+		}
+		flags = append(flags, a)
+		name := strings.TrimLeft(a, "-")
+		if !strings.Contains(name, "=") && isValueFlag("", name) && i+1 < len(args) {
+			flags = append(flags, args[i+1])
+			i++
+		}
+	}
+	return flags
+}
+
+// HasSubcommandFlag checks whether one of the specified boolean flags, given as
+// --name, -name or --name=true, is set for the subcommand. The first element of
+// args is expected to be the executable.
+func HasSubcommandFlag(args []string, names ...string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	subcommand := GetSubcommand(args)
+	start := 2 + len(GetGlobalFlags(args))
+	for i := start; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			if subcommand == "exec" {
+				// The arguments following the container ID are the command.
+				break
+			}
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !hasValue && isValueFlag(subcommand, name) {
+			i++
+			continue
+		}
+		for _, n := range names {
+			if name != n {
+				continue
+			}
+			if !hasValue {
+				return true
+			}
+			set, err := strconv.ParseBool(value)
+			return err == nil && set
+		}
+	}
 	return false
 }
+
+// RemoveGlobalFlag removes the specified flag, given as --name value or
+// --name=value, from the global flags preceding the subcommand and returns its
+// value together with the remaining arguments. This allows flags that are
+// handled by the shim to be passed as runtime arguments without being forwarded
+// to the low-level runtime. The first element of args is expected to be the
+// executable.
+func RemoveGlobalFlag(args []string, name string) (string, []string, error) {
+	if len(args) < 2 {
+		return "", args, nil
+	}
+
+	var value string
+	remaining := []string{args[0]}
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || !strings.HasPrefix(a, "-") || a == "-" {
+			// The subcommand and its arguments are kept unchanged.
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+
+		flag, flagValue, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if flag == name {
+			if hasValue {
+				value = flagValue
+				continue
+			}
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("%v option requires an argument", name)
+			}
+			value = args[i+1]
+			i++
+			continue
+		}
+
+		remaining = append(remaining, a)
+		if !hasValue && isValueFlag("", flag) && i+1 < len(args) {
+			remaining = append(remaining, args[i+1])
+			i++
+		}
+	}
+	return value, remaining, nil
+}
@@ -0,0 +1,249 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetSubcommandAndContainerID(t *testing.T) {
+	testCases := []struct {
+		description string
+		args        []string
+		subcommand  string
+		containerID string
+	}{
+		{
+			description: "no arguments",
+			args:        []string{"runtime"},
+		},
+		{
+			description: "subcommand only",
+			args:        []string{"runtime", "list"},
+			subcommand:  "list",
+		},
+		{
+			description: "create with bundle flag",
+			args:        []string{"runtime", "create", "--bundle", "/bundle", "id"},
+			subcommand:  "create",
+			containerID: "id",
+		},
+		{
+			description: "bundle value named create",
+			args:        []string{"runtime", "run", "-b", "create", "id"},
+			subcommand:  "run",
+			containerID: "id",
+		},
+		{
+			description: "global flags with values",
+			args:        []string{"runtime", "--root", "/run/runc", "--log", "/log.json", "--debug", "delete", "--force", "id"},
+			subcommand:  "delete",
+			containerID: "id",
+		},
+		{
+			description: "flags with inline values",
+			args:        []string{"runtime", "--root=/run/runc", "create", "--bundle=/bundle", "--pid-file=/pid", "id"},
+			subcommand:  "create",
+			containerID: "id",
+		},
+		{
+			description: "kill with signal",
+			args:        []string{"runtime", "kill", "--all", "id", "KILL"},
+			subcommand:  "kill",
+			containerID: "id",
+		},
+		{
+			description: "exec with process flags",
+			args:        []string{"runtime", "exec", "-e", "A=B", "--cwd", "/", "id", "sh"},
+			subcommand:  "exec",
+			containerID: "id",
+		},
+		{
+			description: "update with resource values",
+			args:        []string{"runtime", "update", "--memory", "1G", "-m", "2G", "--cpu-share", "512", "-r", "/resources.json", "id"},
+			subcommand:  "update",
+			containerID: "id",
+		},
+		{
+			description: "ps with format",
+			args:        []string{"runtime", "ps", "--format", "json", "id", "-ef"},
+			subcommand:  "ps",
+			containerID: "id",
+		},
+		{
+			description: "checkpoint with page server",
+			args:        []string{"runtime", "checkpoint", "--image-path", "/images", "--page-server", "10.0.0.1:27", "id"},
+			subcommand:  "checkpoint",
+			containerID: "id",
+		},
+		{
+			description: "restore with page server and bundle",
+			args:        []string{"runtime", "restore", "--page-server", "10.0.0.1:27", "-b", "/bundle", "id"},
+			subcommand:  "restore",
+			containerID: "id",
+		},
+		{
+			description: "flag value of another subcommand",
+			args:        []string{"runtime", "delete", "-f", "id"},
+			subcommand:  "delete",
+			containerID: "id",
+		},
+		{
+			description: "exec command arguments are not parsed",
+			args:        []string{"runtime", "exec", "id", "ls", "-e", "dir"},
+			subcommand:  "exec",
+			containerID: "id",
+		},
+		{
+			description: "end of flags",
+			args:        []string{"runtime", "start", "--", "-id"},
+			subcommand:  "start",
+			containerID: "-id",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if subcommand := GetSubcommand(tc.args); subcommand != tc.subcommand {
+				t.Errorf("expected subcommand %q, got %q", tc.subcommand, subcommand)
+			}
+			if containerID := GetContainerID(tc.args); containerID != tc.containerID {
+				t.Errorf("expected container ID %q, got %q", tc.containerID, containerID)
+			}
+		})
+	}
+}
+
+func TestHasBundleSubcommand(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{args: []string{"runtime", "create", "id"}, expected: true},
+		{args: []string{"runtime", "run", "id"}, expected: true},
+		{args: []string{"runtime", "restore", "id"}, expected: true},
+		{args: []string{"runtime", "start", "id"}, expected: false},
+		{args: []string{"runtime", "--bundle", "create", "start", "id"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := HasBundleSubcommand(tc.args); actual != tc.expected {
+			t.Errorf("%v: expected %v, got %v", tc.args, tc.expected, actual)
+		}
+	}
+}
+
+func TestRemoveGlobalFlag(t *testing.T) {
+	testCases := []struct {
+		description string
+		args        []string
+		value       string
+		remaining   []string
+		expectError bool
+	}{
+		{
+			description: "flag with separate value",
+			args:        []string{"runtime", "--config", "/config.toml", "--root", "/run", "create", "id"},
+			value:       "/config.toml",
+			remaining:   []string{"runtime", "--root", "/run", "create", "id"},
+		},
+		{
+			description: "flag with inline value",
+			args:        []string{"runtime", "--config=/config.toml", "delete", "id"},
+			value:       "/config.toml",
+			remaining:   []string{"runtime", "delete", "id"},
+		},
+		{
+			description: "subcommand flag is kept",
+			args:        []string{"runtime", "exec", "--config", "/process.json", "id"},
+			remaining:   []string{"runtime", "exec", "--config", "/process.json", "id"},
+		},
+		{
+			description: "missing value",
+			args:        []string{"runtime", "--config"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			value, remaining, err := RemoveGlobalFlag(tc.args, "config")
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != tc.value {
+				t.Errorf("expected value %q, got %q", tc.value, value)
+			}
+			if !slices.Equal(remaining, tc.remaining) {
+				t.Errorf("expected remaining args %v, got %v", tc.remaining, remaining)
+			}
+		})
+	}
+}
+
+func TestGetGlobalFlags(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{"runtime", "delete", "id"}},
+		{
+			args:     []string{"runtime", "--root", "/run/runc", "--log=/log.json", "--systemd-cgroup", "kill", "--all", "id"},
+			expected: []string{"--root", "/run/runc", "--log=/log.json", "--systemd-cgroup"},
+		},
+	}
+
+	for _, tc := range testCases {
+		if flags := GetGlobalFlags(tc.args); !slices.Equal(flags, tc.expected) {
+			t.Errorf("%v: expected %v, got %v", tc.args, tc.expected, flags)
+		}
+	}
+}
+
+func TestHasSubcommandFlag(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		names    []string
+		expected bool
+	}{
+		{args: []string{"runtime", "kill", "--all", "id"}, names: []string{"all", "a"}, expected: true},
+		{args: []string{"runtime", "kill", "-a", "id", "KILL"}, names: []string{"all", "a"}, expected: true},
+		{args: []string{"runtime", "kill", "id", "KILL"}, names: []string{"all", "a"}},
+		{args: []string{"runtime", "delete", "--force=true", "id"}, names: []string{"force", "f"}, expected: true},
+		{args: []string{"runtime", "delete", "--force=false", "id"}, names: []string{"force", "f"}},
+		{args: []string{"runtime", "--root", "/run", "delete", "-f", "id"}, names: []string{"force", "f"}, expected: true},
+		{args: []string{"runtime", "--root", "--all", "kill", "id"}, names: []string{"all", "a"}},
+		{args: []string{"runtime", "exec", "--cwd", "--all", "id"}, names: []string{"all", "a"}},
+		{args: []string{"runtime", "exec", "id", "ls", "--all"}, names: []string{"all", "a"}},
+		{args: []string{"runtime", "ps", "-f", "json", "id"}, names: []string{"force", "f"}},
+		{args: []string{"runtime", "kill", "id", "KILL", "--all"}, names: []string{"all", "a"}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		if actual := HasSubcommandFlag(tc.args, tc.names...); actual != tc.expected {
+			t.Errorf("%v: expected %v, got %v", tc.args, tc.expected, actual)
+		}
+	}
+}
@@ -17,6 +17,8 @@
 
 package oci
 
+import "github.com/opencontainers/runtime-spec/specs-go"
+
 //go:generate moq -stub -out runtime_mock.go . Runtime
 
 // Runtime is an interface for a runtime shim. The Exec method accepts a list
@@ -24,3 +26,11 @@ package oci
 type Runtime interface {
 	Exec([]string) error
 }
+
+// StateQuerier is implemented by runtimes that can query the state of a
+// container without replacing the current process.
+type StateQuerier interface {
+	// State returns the state of the container with the specified ID. The
+	// global flags are passed to the runtime ahead of the state subcommand.
+	State(globalFlags []string, containerID string) (*specs.State, error)
+}
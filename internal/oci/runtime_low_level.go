@@ -19,6 +19,7 @@ package oci
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"gitee.com/deep-spark/ix-container-runtime/internal/lookup"
 	log "github.com/sirupsen/logrus"
@@ -26,15 +27,16 @@ import (
 
 // NewLowLevelRuntime creates a Runtime that wraps a low-level runtime executable.
 // The executable specified is taken from the list of supplied candidates, with the first match
-// present in the PATH being selected. A logger is also specified.
-func NewLowLevelRuntime(candidates []string) (Runtime, error) {
+// present in the PATH being selected. Candidates specified as absolute paths are used as-is.
+// The extra arguments are passed to the runtime ahead of the arguments supplied to Exec.
+func NewLowLevelRuntime(candidates []string, extraArgs ...string) (Runtime, error) {
 	runtimePath, err := findRuntime(candidates)
 	if err != nil {
 		return nil, fmt.Errorf("error locating runtime: %v", err)
 	}
 
 	log.Infof("Using low-level runtime %v", runtimePath)
-	return NewRuntimeForPath(runtimePath)
+	return NewRuntimeForPath(runtimePath, extraArgs...)
 }
 
 // findRuntime checks elements in a list of supplied candidates for a matching executable in the PATH.
@@ -46,6 +48,15 @@ func findRuntime(candidates []string) (string, error) {
 
 	locator := lookup.NewExecutableLocator("/")
 	for _, candidate := range candidates {
+		if filepath.IsAbs(candidate) {
+			log.Debugf("Checking runtime binary '%v'", candidate)
+			if err := assertRuntimeExecutable(candidate); err != nil {
+				log.Debugf("Runtime binary '%v' not usable: %v", candidate, err)
+				continue
+			}
+			return candidate, nil
+		}
+
 		log.Debugf("Looking for runtime binary '%v'", candidate)
 		targets, err := locator.Locate(candidate)
 		if err == nil && len(targets) > 0 {
@@ -29,6 +29,21 @@ type modifyingRuntimeWrapper struct {
 	modifier SpecModifier
 }
 
+// ModificationError is returned by the modifying runtime wrapper if the OCI
+// specification could not be modified. In this case the wrapped runtime has
+// not been executed and the specification on disk is unchanged.
+type ModificationError struct {
+	Err error
+}
+
+func (e *ModificationError) Error() string {
+	return fmt.Sprintf("could not apply required modification to OCI specification: %v", e.Err)
+}
+
+func (e *ModificationError) Unwrap() error {
+	return e.Err
+}
+
 func NewModifyingRuntimeWrapper(runtime Runtime, spec Spec, modifier SpecModifier) Runtime {
 	if modifier == nil {
 		return runtime
@@ -43,10 +58,10 @@ func NewModifyingRuntimeWrapper(runtime Runtime, spec Spec, modifier SpecModifie
 }
 
 func (r *modifyingRuntimeWrapper) Exec(args []string) error {
-	if HasCreateSubcommand(args) {
+	if HasBundleSubcommand(args) {
 		err := r.modify()
 		if err != nil {
-			return fmt.Errorf("could not apply required modification to OCI specification: %v", err)
+			return &ModificationError{err}
 		}
 		log.Printf("Applied required modification to OCI specification")
 	} else {
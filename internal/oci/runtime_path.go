@@ -18,8 +18,14 @@
 package oci
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // pathRuntime wraps the path that a binary and defines the semantics for how to exec into it.
@@ -27,36 +33,72 @@ import (
 // Runtime internface.
 type pathRuntime struct {
 	path        string
+	args        []string
 	execRuntime Runtime
 }
 
 var _ Runtime = (*pathRuntime)(nil)
+var _ StateQuerier = (*pathRuntime)(nil)
 
-// NewRuntimeForPath creates a Runtime for the specified logger and path
-func NewRuntimeForPath(path string) (Runtime, error) {
-	info, err := os.Stat(path)
-	if err != nil {
-		return nil, fmt.Errorf("invalid path '%v': %v", path, err)
-	}
-	if info.IsDir() || info.Mode()&0111 == 0 {
-		return nil, fmt.Errorf("specified path '%v' is not an executable file", path)
+// NewRuntimeForPath creates a Runtime for the specified path. The optional
+// extra arguments are inserted directly after the path of the binary.
+func NewRuntimeForPath(path string, extraArgs ...string) (Runtime, error) {
+	if err := assertRuntimeExecutable(path); err != nil {
+		return nil, err
 	}
 
 	shim := pathRuntime{
 		path:        path,
+		args:        extraArgs,
 		execRuntime: syscallExec{},
 	}
 
 	return &shim, nil
 }
 
+// assertRuntimeExecutable checks whether the specified path is an executable file.
+func assertRuntimeExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("invalid path '%v': %v", path, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("specified path '%v' is not an executable file", path)
+	}
+	return nil
+}
+
 // Exec exces into the binary at the path from the pathRuntime struct, passing it the supplied arguments
 // after ensuring that the first argument is the path of the target binary.
 func (s pathRuntime) Exec(args []string) error {
 	runtimeArgs := []string{s.path}
+	runtimeArgs = append(runtimeArgs, s.args...)
 	if len(args) > 1 {
 		runtimeArgs = append(runtimeArgs, args[1:]...)
 	}
 
 	return s.execRuntime.Exec(runtimeArgs)
 }
+
+// State runs the state subcommand of the binary for the specified container
+// and returns the decoded state.
+func (s pathRuntime) State(globalFlags []string, containerID string) (*specs.State, error) {
+	args := append([]string{}, s.args...)
+	args = append(args, globalFlags...)
+	args = append(args, "state", containerID)
+
+	//nolint:gosec // The arguments are those of the current invocation of the runtime.
+	cmd := exec.Command(s.path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state of container %v: %v: %v", containerID, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var state specs.State
+	if err := json.Unmarshal(output, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode state of container %v: %v", containerID, err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,194 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// SpecDiff describes the additions made to an OCI spec by a SpecModifier.
+type SpecDiff struct {
+	Devices     []specs.LinuxDevice       `json:"devices,omitempty"`
+	DeviceRules []specs.LinuxDeviceCgroup `json:"deviceRules,omitempty"`
+	Mounts      []specs.Mount             `json:"mounts,omitempty"`
+	// Env holds the environment variables that were added or changed.
+	Env   []string                `json:"env,omitempty"`
+	Hooks map[string][]specs.Hook `json:"hooks,omitempty"`
+}
+
+// CopySpec returns a deep copy of the specified OCI spec.
+func CopySpec(spec *specs.Spec) (*specs.Spec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OCI spec: %v", err)
+	}
+	var copied specs.Spec
+	err = json.Unmarshal(data, &copied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OCI spec: %v", err)
+	}
+	return &copied, nil
+}
+
+// DiffSpecs returns the devices, device cgroup rules, mounts, environment
+// variables and hooks that are present in the modified spec but not in the
+// original. Devices are compared by path and mounts by destination.
+func DiffSpecs(original *specs.Spec, modified *specs.Spec) *SpecDiff {
+	d := SpecDiff{}
+
+	devices := make(map[string]bool)
+	rules := make(map[string]bool)
+	for _, device := range linuxDevices(original) {
+		devices[device.Path] = true
+	}
+	for _, rule := range deviceRules(original) {
+		rules[DeviceRuleString(rule)] = true
+	}
+	for _, device := range linuxDevices(modified) {
+		if devices[device.Path] {
+			continue
+		}
+		devices[device.Path] = true
+		d.Devices = append(d.Devices, device)
+	}
+	for _, rule := range deviceRules(modified) {
+		if rules[DeviceRuleString(rule)] {
+			continue
+		}
+		rules[DeviceRuleString(rule)] = true
+		d.DeviceRules = append(d.DeviceRules, rule)
+	}
+
+	mounts := make(map[string]bool)
+	for _, m := range original.Mounts {
+		mounts[filepath.Clean(m.Destination)] = true
+	}
+	for _, m := range modified.Mounts {
+		if mounts[filepath.Clean(m.Destination)] {
+			continue
+		}
+		mounts[filepath.Clean(m.Destination)] = true
+		d.Mounts = append(d.Mounts, m)
+	}
+
+	env := make(map[string]bool)
+	for _, e := range processEnv(original) {
+		env[e] = true
+	}
+	for _, e := range processEnv(modified) {
+		if !env[e] {
+			d.Env = append(d.Env, e)
+		}
+	}
+
+	hooks := make(map[string]bool)
+	for stage, stageHooks := range specHooks(original) {
+		for _, h := range stageHooks {
+			hooks[stage+":"+hookString(h)] = true
+		}
+	}
+	for stage, stageHooks := range specHooks(modified) {
+		for _, h := range stageHooks {
+			if hooks[stage+":"+hookString(h)] {
+				continue
+			}
+			if d.Hooks == nil {
+				d.Hooks = make(map[string][]specs.Hook)
+			}
+			d.Hooks[stage] = append(d.Hooks[stage], h)
+		}
+	}
+
+	return &d
+}
+
+// IsEmpty returns whether the diff contains no changes.
+func (d *SpecDiff) IsEmpty() bool {
+	return len(d.Devices) == 0 && len(d.DeviceRules) == 0 && len(d.Mounts) == 0 && len(d.Env) == 0 && len(d.Hooks) == 0
+}
+
+// DeviceRuleString returns the device cgroup rule in the format used by the
+// devices.allow file of the cgroup v1 devices controller.
+func DeviceRuleString(r specs.LinuxDeviceCgroup) string {
+	t := r.Type
+	if t == "" {
+		t = "a"
+	}
+	major, minor := "*", "*"
+	if r.Major != nil {
+		major = fmt.Sprintf("%d", *r.Major)
+	}
+	if r.Minor != nil {
+		minor = fmt.Sprintf("%d", *r.Minor)
+	}
+	access := r.Access
+	if access == "" {
+		access = "rwm"
+	}
+	return fmt.Sprintf("%s %s:%s %s", t, major, minor, access)
+}
+
+func linuxDevices(spec *specs.Spec) []specs.LinuxDevice {
+	if spec.Linux == nil {
+		return nil
+	}
+	return spec.Linux.Devices
+}
+
+func deviceRules(spec *specs.Spec) []specs.LinuxDeviceCgroup {
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil
+	}
+	var allowed []specs.LinuxDeviceCgroup
+	for _, r := range spec.Linux.Resources.Devices {
+		if r.Allow {
+			allowed = append(allowed, r)
+		}
+	}
+	return allowed
+}
+
+func processEnv(spec *specs.Spec) []string {
+	if spec.Process == nil {
+		return nil
+	}
+	return spec.Process.Env
+}
+
+func specHooks(spec *specs.Spec) map[string][]specs.Hook {
+	if spec.Hooks == nil {
+		return nil
+	}
+	return map[string][]specs.Hook{
+		"prestart":        spec.Hooks.Prestart,
+		"createRuntime":   spec.Hooks.CreateRuntime,
+		"createContainer": spec.Hooks.CreateContainer,
+		"startContainer":  spec.Hooks.StartContainer,
+		"poststart":       spec.Hooks.Poststart,
+		"poststop":        spec.Hooks.Poststop,
+	}
+}
+
+func hookString(h specs.Hook) string {
+	return h.Path + " " + strings.Join(h.Args, " ")
+}
@@ -18,17 +18,27 @@
 package oci
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 
 	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/atomicfile"
 )
 
 type fileSpec struct {
 	memorySpec
 	path string
+	// raw is the decoded content of the file as loaded. It is used to retain
+	// fields that are not known to specs.Spec when the spec is flushed.
+	raw map[string]interface{}
+	// known is the content of the file as represented by specs.Spec.
+	known map[string]interface{}
+	mode  os.FileMode
 }
 
 func NewFileSpec(filepath string) Spec {
@@ -40,17 +50,34 @@ func NewFileSpec(filepath string) Spec {
 }
 
 func (s *fileSpec) Load() (*specs.Spec, error) {
-	specFile, err := os.Open(s.path)
+	data, err := os.ReadFile(s.path)
 	if err != nil {
 		return nil, fmt.Errorf("error opening OCI specification file: %v", err)
 	}
-	defer specFile.Close()
 
-	spec, err := LoadFrom(specFile)
+	spec, err := LoadFrom(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error loading OCI specification from file: %v", err)
+	}
+
+	raw, err := decodeObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading OCI specification from file: %v", err)
+	}
+	known, err := toObject(spec)
 	if err != nil {
 		return nil, fmt.Errorf("error loading OCI specification from file: %v", err)
 	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OCI specification file mode: %v", err)
+	}
+
 	s.Spec = spec
+	s.raw = raw
+	s.known = known
+	s.mode = info.Mode().Perm()
 	return s.Spec, nil
 }
 
@@ -73,32 +100,191 @@ func LoadFrom(reader io.Reader) (*specs.Spec, error) {
 	return &spec, nil
 }
 
+// Flush writes the stored OCI specification to the file. Fields of the loaded
+// file that are not known to specs.Spec are retained. The file is replaced
+// atomically by writing to a temporary file in the same directory and renaming
+// it over the original.
 func (s fileSpec) Flush() error {
 	if s.Spec == nil {
 		return fmt.Errorf("no OCI specification loaded")
 	}
 
-	specFile, err := os.Create(s.path)
+	content, err := toObject(s.Spec)
 	if err != nil {
-		return fmt.Errorf("error opening OCI specification file: %v", err)
+		return fmt.Errorf("error encoding OCI specification: %v", err)
 	}
-	defer specFile.Close()
+	mergeUnknown(content, s.raw, s.known)
 
-	err = flushTo(s.Spec, specFile)
-	return err
+	mode := s.mode
+	if mode == 0 {
+		mode = 0644
+	}
+	return writeFileAtomic(s.path, content, mode)
+}
+
+// writeFileAtomic writes the JSON encoding of the content to the specified path.
+func writeFileAtomic(path string, content interface{}, mode os.FileMode) error {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("error encoding OCI specification: %v", err)
+	}
+	if err := atomicfile.WriteFile(path, append(data, '\n'), mode); err != nil {
+		return fmt.Errorf("error writing OCI specification: %v", err)
+	}
+	return nil
 }
 
-// flushTo writes the stored OCI specification to the specified io.Writer.
-func flushTo(spec *specs.Spec, writer io.Writer) error {
-	if spec == nil {
-		return nil
+// mergeUnknown adds the fields of raw that are not present in known to content.
+// Fields present in known are owned by specs.Spec and are taken from content as
+// is, so that fields removed by a modifier are not restored. Nested objects are
+// merged recursively and objects in arrays are merged with the element they were
+// loaded from (see mergeUnknownElements).
+func mergeUnknown(content map[string]interface{}, raw map[string]interface{}, known map[string]interface{}) {
+	for key, rawValue := range raw {
+		knownValue, isKnown := known[key]
+		if !isKnown {
+			if _, exists := content[key]; !exists {
+				content[key] = rawValue
+			}
+			continue
+		}
+
+		switch rawValue := rawValue.(type) {
+		case map[string]interface{}:
+			knownObject, ok := knownValue.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			contentObject, ok := content[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mergeUnknown(contentObject, rawValue, knownObject)
+		case []interface{}:
+			knownArray, ok := knownValue.([]interface{})
+			if !ok || len(knownArray) != len(rawValue) {
+				continue
+			}
+			contentArray, ok := content[key].([]interface{})
+			if !ok {
+				continue
+			}
+			mergeUnknownElements(contentArray, rawValue, knownArray)
+		}
 	}
-	encoder := json.NewEncoder(writer)
+}
+
+// elementKeys are the fields that identify an element of an array in the OCI
+// spec, e.g. the destination of a mount or the path of a device or hook.
+var elementKeys = []string{"destination", "path"}
 
-	err := encoder.Encode(spec)
+// mergeUnknownElements merges the unknown fields of the loaded array elements
+// into the elements of content. Since modifiers may add, remove or reorder
+// elements, an element of content is matched with the loaded element whose known
+// fields are unchanged or, failing that, with the only loaded element that has
+// the same destination or path. Unmatched elements are left as is.
+func mergeUnknownElements(content []interface{}, raw []interface{}, known []interface{}) {
+	used := make([]bool, len(raw))
+	var unmatched []int
+	for i, c := range content {
+		j := -1
+		for k := range known {
+			if !used[k] && reflect.DeepEqual(known[k], c) {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			unmatched = append(unmatched, i)
+			continue
+		}
+		used[j] = true
+		mergeElement(content[i], raw[j], known[j])
+	}
+
+	for _, i := range unmatched {
+		contentObject, ok := content[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range elementKeys {
+			value, ok := contentObject[key].(string)
+			if !ok {
+				continue
+			}
+			if countElements(content, key, value) != 1 {
+				break
+			}
+			if j := findElement(known, key, value); j >= 0 && !used[j] {
+				used[j] = true
+				mergeElement(content[i], raw[j], known[j])
+			}
+			break
+		}
+	}
+}
+
+func mergeElement(content interface{}, raw interface{}, known interface{}) {
+	contentObject, ok := content.(map[string]interface{})
+	if !ok {
+		return
+	}
+	rawObject, ok := raw.(map[string]interface{})
+	if !ok {
+		return
+	}
+	knownObject, ok := known.(map[string]interface{})
+	if !ok {
+		return
+	}
+	mergeUnknown(contentObject, rawObject, knownObject)
+}
+
+// countElements returns the number of objects in the array with the specified
+// value for the key.
+func countElements(array []interface{}, key string, value string) int {
+	var count int
+	for _, e := range array {
+		if object, ok := e.(map[string]interface{}); ok && object[key] == value {
+			count++
+		}
+	}
+	return count
+}
+
+// findElement returns the index of the only object in the array with the
+// specified value for the key, or -1 if there is none or more than one.
+func findElement(array []interface{}, key string, value string) int {
+	if countElements(array, key, value) != 1 {
+		return -1
+	}
+	for i, e := range array {
+		if object, ok := e.(map[string]interface{}); ok && object[key] == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// toObject returns the OCI specification as a generic JSON object.
+func toObject(spec *specs.Spec) (map[string]interface{}, error) {
+	data, err := json.Marshal(spec)
 	if err != nil {
-		return fmt.Errorf("error writing OCI specification: %v", err)
+		return nil, err
 	}
+	return decodeObject(data)
+}
 
-	return nil
+// decodeObject decodes a JSON object, retaining numbers as json.Number so that
+// they are written unchanged.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	err := decoder.Decode(&object)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OCI specification: %v", err)
+	}
+	return object, nil
 }
@@ -0,0 +1,122 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package oci
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+type modifierFunc func(*specs.Spec) error
+
+func (f modifierFunc) Modify(spec *specs.Spec) error {
+	return f(spec)
+}
+
+func TestFlushRetainsUnknownFieldsInArrays(t *testing.T) {
+	original := `{
+	"ociVersion": "1.0.2",
+	"x-top": "top",
+	"mounts": [
+		{"destination": "/proc", "type": "proc", "source": "proc", "x-mount": "proc"},
+		{"destination": "/data", "type": "bind", "source": "/data", "x-mount": "data"}
+	],
+	"hooks": {
+		"prestart": [{"path": "/bin/hook", "x-hook": "hook"}]
+	},
+	"linux": {
+		"devices": [
+			{"path": "/dev/iluvatar0", "type": "c", "major": 510, "minor": 0, "x-device": "0"},
+			{"path": "/dev/iluvatar1", "type": "c", "major": 510, "minor": 1, "x-device": "1"}
+		],
+		"resources": {
+			"devices": [{"allow": false, "access": "rwm", "x-rule": "deny"}]
+		}
+	}
+}`
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := NewFileSpec(path)
+	if _, err := spec.Load(); err != nil {
+		t.Fatal(err)
+	}
+	err := spec.Modify(modifierFunc(func(s *specs.Spec) error {
+		// Prepend a mount, change the source of a mount, remove a device and
+		// append a hook and a device rule.
+		s.Mounts = append([]specs.Mount{{Destination: "/new", Source: "/new"}}, s.Mounts...)
+		s.Mounts[2].Source = "/other"
+		s.Linux.Devices = s.Linux.Devices[1:]
+		s.Hooks.Prestart = append(s.Hooks.Prestart, specs.Hook{Path: "/bin/other"})
+		s.Linux.Resources.Devices = append(s.Linux.Resources.Devices, specs.LinuxDeviceCgroup{Allow: true, Access: "rw"})
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flushed struct {
+		Top    string              `json:"x-top"`
+		Mounts []map[string]string `json:"mounts"`
+		Hooks  struct {
+			Prestart []map[string]string `json:"prestart"`
+		} `json:"hooks"`
+		Linux struct {
+			Devices   []map[string]interface{} `json:"devices"`
+			Resources struct {
+				Devices []map[string]interface{} `json:"devices"`
+			} `json:"resources"`
+		} `json:"linux"`
+	}
+	if err := json.Unmarshal(data, &flushed); err != nil {
+		t.Fatal(err)
+	}
+
+	if flushed.Top != "top" {
+		t.Errorf("expected top-level unknown field to be retained")
+	}
+	expectedMounts := []string{"", "proc", "data"}
+	for i, m := range flushed.Mounts {
+		if m["x-mount"] != expectedMounts[i] {
+			t.Errorf("mount %v: expected unknown field %q, got %q", m["destination"], expectedMounts[i], m["x-mount"])
+		}
+	}
+	if len(flushed.Linux.Devices) != 1 || flushed.Linux.Devices[0]["x-device"] != "1" {
+		t.Errorf("expected unknown field of remaining device to be retained: %v", flushed.Linux.Devices)
+	}
+	if flushed.Hooks.Prestart[0]["x-hook"] != "hook" || flushed.Hooks.Prestart[1]["x-hook"] != "" {
+		t.Errorf("unexpected unknown fields of hooks: %v", flushed.Hooks.Prestart)
+	}
+	rules := flushed.Linux.Resources.Devices
+	if rules[0]["x-rule"] != "deny" || rules[1]["x-rule"] != nil {
+		t.Errorf("unexpected unknown fields of device rules: %v", rules)
+	}
+}
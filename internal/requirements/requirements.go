@@ -0,0 +1,224 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package requirements
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// operators are the supported comparison operators. Operators that are a prefix
+// of another operator are listed after it.
+var operators = []string{">=", "<=", "==", "!=", ">", "<", "="}
+
+// Requirements holds the versions of the host properties (e.g. the driver) and
+// the constraints on them declared by a container.
+type Requirements struct {
+	properties  map[string]string
+	constraints []string
+}
+
+// New creates an empty set of requirements.
+func New() *Requirements {
+	return &Requirements{
+		properties: make(map[string]string),
+	}
+}
+
+// AddVersionProperty sets the version of the named host property. An empty
+// version marks the property as unknown.
+func (r *Requirements) AddVersionProperty(name string, version string) {
+	r.properties[name] = version
+}
+
+// AddConstraint adds the specified requirements. Each requirement is a list of
+// space-separated alternatives, any of which must be met. An alternative is a
+// list of comma-separated constraints such as driver>=4.1, all of which must be
+// met.
+func (r *Requirements) AddConstraint(requirements ...string) {
+	r.constraints = append(r.constraints, requirements...)
+}
+
+// Assert checks that all requirements are met. The returned error lists the
+// requirements that are not met.
+func (r *Requirements) Assert() error {
+	var unmet []string
+	for _, requirement := range r.constraints {
+		met, err := r.met(requirement)
+		if err != nil {
+			return fmt.Errorf("invalid requirement %q: %v", requirement, err)
+		}
+		if !met {
+			unmet = append(unmet, requirement)
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unsatisfied requirements %q with %v", unmet, r.describeProperties())
+}
+
+// met checks whether any of the alternatives of the requirement is met.
+func (r *Requirements) met(requirement string) (bool, error) {
+	alternatives := strings.Fields(requirement)
+	if len(alternatives) == 0 {
+		return true, nil
+	}
+	for _, alternative := range alternatives {
+		met, err := r.metAll(alternative)
+		if err != nil {
+			return false, err
+		}
+		if met {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// metAll checks whether all comma-separated constraints are met.
+func (r *Requirements) metAll(alternative string) (bool, error) {
+	for _, constraint := range strings.Split(alternative, ",") {
+		if constraint == "" {
+			continue
+		}
+		met, err := r.metConstraint(constraint)
+		if err != nil {
+			return false, err
+		}
+		if !met {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *Requirements) metConstraint(constraint string) (bool, error) {
+	name, operator, value, err := parseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	current, ok := r.properties[name]
+	if !ok {
+		return false, fmt.Errorf("unknown property %q", name)
+	}
+	if current == "" {
+		return false, fmt.Errorf("the %v version of the host is unknown", name)
+	}
+
+	required, err := normalizeVersion(value)
+	if err != nil {
+		return false, err
+	}
+	actual, err := normalizeVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("invalid %v version of the host: %v", name, err)
+	}
+
+	c := semver.Compare(actual, required)
+	switch operator {
+	case ">=":
+		return c >= 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case "<":
+		return c < 0, nil
+	case "=", "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	}
+	return false, fmt.Errorf("unsupported operator %q", operator)
+}
+
+// parseConstraint splits a constraint such as driver>=4.1 into the property,
+// the operator and the version.
+func parseConstraint(constraint string) (string, string, string, error) {
+	for i := range constraint {
+		for _, operator := range operators {
+			if !strings.HasPrefix(constraint[i:], operator) {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(constraint[:i]))
+			value := strings.TrimSpace(constraint[i+len(operator):])
+			if name == "" || value == "" {
+				return "", "", "", fmt.Errorf("invalid constraint %q", constraint)
+			}
+			return name, operator, value, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("invalid constraint %q: missing operator", constraint)
+}
+
+// normalizeVersion converts a version such as 4.1 or 4.1.0.20240501 to a
+// semantic version with at most three components. Leading zeros of a component
+// (e.g. 4.01) are dropped and trailing non-numeric parts (e.g. -rc1) are ignored.
+func normalizeVersion(version string) (string, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	end := strings.IndexFunc(v, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.'
+	})
+	if end >= 0 {
+		v = v[:end]
+	}
+
+	var components []string
+	for _, c := range strings.Split(v, ".") {
+		if c == "" {
+			break
+		}
+		if c = strings.TrimLeft(c, "0"); c == "" {
+			c = "0"
+		}
+		components = append(components, c)
+	}
+	if len(components) > 3 {
+		components = components[:3]
+	}
+
+	normalized := "v" + strings.Join(components, ".")
+	if len(components) == 0 || !semver.IsValid(normalized) {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	return normalized, nil
+}
+
+// describeProperties returns the versions of the host properties for use in
+// error messages.
+func (r *Requirements) describeProperties() string {
+	var names []string
+	for name := range r.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var described []string
+	for _, name := range names {
+		version := r.properties[name]
+		if version == "" {
+			version = "unknown"
+		}
+		described = append(described, name+"="+version)
+	}
+	return strings.Join(described, ", ")
+}
@@ -0,0 +1,208 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package requirements
+
+import "testing"
+
+func TestParseConstraint(t *testing.T) {
+	testCases := []struct {
+		constraint  string
+		name        string
+		operator    string
+		value       string
+		expectError bool
+	}{
+		{constraint: "driver>=4.1", name: "driver", operator: ">=", value: "4.1"},
+		{constraint: "driver<=4.1", name: "driver", operator: "<=", value: "4.1"},
+		{constraint: "cuda==10.2", name: "cuda", operator: "==", value: "10.2"},
+		{constraint: "cuda=10.2", name: "cuda", operator: "=", value: "10.2"},
+		{constraint: "driver!=4.1.0", name: "driver", operator: "!=", value: "4.1.0"},
+		{constraint: "driver>4", name: "driver", operator: ">", value: "4"},
+		{constraint: "driver<4", name: "driver", operator: "<", value: "4"},
+		{constraint: " Driver >= 4.1 ", name: "driver", operator: ">=", value: "4.1"},
+		{constraint: "driver4.1", expectError: true},
+		{constraint: ">=4.1", expectError: true},
+		{constraint: "driver>=", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			name, operator, value, err := parseConstraint(tc.constraint)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tc.name || operator != tc.operator || value != tc.value {
+				t.Errorf("expected (%q, %q, %q), got (%q, %q, %q)", tc.name, tc.operator, tc.value, name, operator, value)
+			}
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	testCases := []struct {
+		version     string
+		expected    string
+		expectError bool
+	}{
+		{version: "4", expected: "v4"},
+		{version: "4.1", expected: "v4.1"},
+		{version: "4.1.0", expected: "v4.1.0"},
+		{version: "v4.1.0", expected: "v4.1.0"},
+		{version: "4.1.0.20240501", expected: "v4.1.0"},
+		{version: "4.1.0-rc1", expected: "v4.1.0"},
+		{version: " 10.2 ", expected: "v10.2"},
+		{version: "4.01", expected: "v4.1"},
+		{version: "04.001.00", expected: "v4.1.0"},
+		{version: "10.00", expected: "v10.0"},
+		{version: "4.0.0100", expected: "v4.0.100"},
+		{version: "", expectError: true},
+		{version: "latest", expectError: true},
+		{version: ".1", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			normalized, err := normalizeVersion(tc.version)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %q", normalized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if normalized != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestAssert(t *testing.T) {
+	testCases := []struct {
+		description string
+		constraints []string
+		expectError bool
+	}{
+		{
+			description: "no constraints",
+		},
+		{
+			description: "met constraint",
+			constraints: []string{"driver>=4.1"},
+		},
+		{
+			description: "unmet constraint",
+			constraints: []string{"driver>=4.2"},
+			expectError: true,
+		},
+		{
+			description: "comparison is numeric",
+			constraints: []string{"cuda>9.2"},
+		},
+		{
+			description: "equality ignores missing components",
+			constraints: []string{"driver==4.1.0", "driver=4.1"},
+		},
+		{
+			description: "all comma-separated constraints must be met",
+			constraints: []string{"driver>=4.0,driver<4.1"},
+			expectError: true,
+		},
+		{
+			description: "comma-separated constraints met",
+			constraints: []string{"driver>=4.0,cuda>=10.2"},
+		},
+		{
+			description: "any space-separated alternative can be met",
+			constraints: []string{"driver>=5.0 cuda>=10"},
+		},
+		{
+			description: "no alternative met",
+			constraints: []string{"driver>=5.0 cuda>=11"},
+			expectError: true,
+		},
+		{
+			description: "all requirements must be met",
+			constraints: []string{"driver>=4.0", "cuda>=11"},
+			expectError: true,
+		},
+		{
+			description: "corex version met",
+			constraints: []string{"corex>=4.1", "corex==4.1.0"},
+		},
+		{
+			description: "corex version is not the CUDA version",
+			constraints: []string{"corex>=10.2"},
+			expectError: true,
+		},
+		{
+			description: "leading zeros in the requirement",
+			constraints: []string{"driver>=4.01", "corex=04.01.00"},
+		},
+		{
+			description: "leading zeros in the host version",
+			constraints: []string{"cuda==10.2", "cuda<10.10"},
+		},
+		{
+			description: "empty requirement",
+			constraints: []string{"  "},
+		},
+		{
+			description: "unknown property",
+			constraints: []string{"arch>=1"},
+			expectError: true,
+		},
+		{
+			description: "unknown host version",
+			constraints: []string{"firmware>=1"},
+			expectError: true,
+		},
+		{
+			description: "invalid constraint",
+			constraints: []string{"driver"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := New()
+			r.AddVersionProperty("driver", "4.1.0")
+			r.AddVersionProperty("corex", "4.01.0")
+			r.AddVersionProperty("cuda", "10.02")
+			r.AddVersionProperty("firmware", "")
+			r.AddConstraint(tc.constraints...)
+
+			err := r.Assert()
+			if tc.expectError && err == nil {
+				t.Errorf("expected error")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
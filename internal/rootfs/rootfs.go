@@ -0,0 +1,78 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package rootfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks is the maximum number of symlinks followed while resolving a path.
+const maxSymlinks = 255
+
+// Resolve resolves the specified path relative to the root filesystem.
+// Symlinks in the path are followed as if root was the filesystem root so that
+// the resolved path cannot escape it. Components that do not exist are appended
+// as-is.
+func Resolve(root string, path string) (string, error) {
+	var resolved string
+	remaining := filepath.Clean("/" + path)
+	links := 0
+
+	for remaining != "" {
+		var component string
+		remaining = strings.TrimPrefix(remaining, "/")
+		component, remaining, _ = strings.Cut(remaining, "/")
+		if remaining != "" {
+			remaining = "/" + remaining
+		}
+
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir("/" + resolved)
+			resolved = strings.TrimPrefix(resolved, "/")
+			continue
+		}
+
+		candidate := filepath.Join(resolved, component)
+		info, err := os.Lstat(filepath.Join(root, candidate))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many symlinks resolving %v in %v", path, root)
+		}
+		target, err := os.Readlink(filepath.Join(root, candidate))
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink %v: %v", candidate, err)
+		}
+		if filepath.IsAbs(target) {
+			resolved = ""
+		}
+		remaining = "/" + target + remaining
+	}
+
+	return filepath.Join(root, resolved), nil
+}
@@ -0,0 +1,124 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+// cleanupStep releases a resource held by the container with the specified ID.
+type cleanupStep struct {
+	name string
+	run  func(cfg *config.Config, containerID string) error
+	// onDeleteOnly restricts the step to the delete subcommand, e.g. for
+	// resources that are still needed to delete the container.
+	onDeleteOnly bool
+}
+
+// cleanupSteps are run in order before the container is deleted. The record of
+// the container is removed after the leases, which rely on it to detect that
+// their owner no longer exists.
+var cleanupSteps = []cleanupStep{
+	{name: "release device leases", run: releaseLeases},
+	{name: "remove container record", run: removeRecord},
+	{name: "remove runtime selection", run: forgetRuntimeSelection, onDeleteOnly: true},
+}
+
+// cleanupContainer runs the cleanup steps for the container if the arguments
+// delete the container, or kill all processes of a container as done by engines
+// after a failed create, and the container no longer runs. Failures are logged
+// and do not prevent the remaining steps or the low-level runtime from being
+// run.
+func cleanupContainer(cfg *config.Config, runtime oci.Runtime, argv []string, containerID string) {
+	if containerID == "" || !needsCleanup(runtime, argv, containerID) {
+		return
+	}
+
+	isDelete := oci.GetSubcommand(argv) == "delete"
+	for _, step := range cleanupSteps {
+		if step.onDeleteOnly && !isDelete {
+			continue
+		}
+		if err := step.run(cfg, containerID); err != nil {
+			log.Warnf("Failed to %v of container %v: %v", step.name, containerID, err)
+		}
+	}
+}
+
+// needsCleanup checks whether the invocation releases the resources of the
+// container. This is the case for a forced delete and for a delete or kill --all
+// of a container that is stopped or was never started. The state is queried
+// through the low-level runtime so that a running container keeps its resources
+// if the low-level runtime refuses the request. A container unknown to the
+// low-level runtime, e.g. after a failed create, is cleaned up.
+func needsCleanup(runtime oci.Runtime, argv []string, containerID string) bool {
+	switch oci.GetSubcommand(argv) {
+	case "delete":
+		if oci.HasSubcommandFlag(argv, "force", "f") {
+			return true
+		}
+	case "kill":
+		if !oci.HasSubcommandFlag(argv, "all", "a") {
+			return false
+		}
+	default:
+		return false
+	}
+
+	querier, ok := runtime.(oci.StateQuerier)
+	if !ok {
+		log.Warnf("Unable to query state of container %v; not cleaning up", containerID)
+		return false
+	}
+	s, err := querier.State(oci.GetGlobalFlags(argv), containerID)
+	if err != nil {
+		log.Infof("Assuming container %v does not exist: %v", containerID, err)
+		return true
+	}
+	switch s.Status {
+	case specs.StateStopped, specs.StateCreated:
+		return true
+	}
+	log.Infof("Not cleaning up container %v in state %v", containerID, s.Status)
+	return false
+}
+
+// releaseLeases releases the device leases held by the container. Leases are
+// released even if exclusive mode was disabled after they were taken.
+func releaseLeases(cfg *config.Config, containerID string) error {
+	released, err := newLeaseManager(cfg).Release(containerID)
+	if len(released) > 0 {
+		log.Infof("Released devices %v of container %v", released, containerID)
+	}
+	return err
+}
+
+// removeRecord removes the record of the container.
+func removeRecord(cfg *config.Config, containerID string) error {
+	return state.New(cfg.StateDir).Remove(containerID)
+}
+
+// forgetRuntimeSelection removes the low-level runtime selected for the container.
+func forgetRuntimeSelection(_ *config.Config, containerID string) error {
+	return removeRuntimeSelection(containerID)
+}
@@ -0,0 +1,81 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the ix-container-runtime. These are stable so that
+// engines and scripts can distinguish between the causes of a failure.
+const (
+	// ExitCodeError is returned for errors that do not have a dedicated exit code.
+	ExitCodeError = 1
+	// ExitCodeConfig is returned if the runtime configuration could not be loaded.
+	ExitCodeConfig = 2
+	// ExitCodeSpec is returned if the OCI specification could not be read or written.
+	ExitCodeSpec = 3
+	// ExitCodeModifier is returned if the OCI specification could not be modified.
+	ExitCodeModifier = 4
+	// ExitCodeLowLevelRuntime is returned if the low-level runtime could not be found
+	// or executed.
+	ExitCodeLowLevelRuntime = 5
+	// ExitCodeRequirements is returned if the requirements declared by the
+	// container are not met by the host.
+	ExitCodeRequirements = 6
+	// ExitCodeDeviceLease is returned if the devices requested by the container
+	// could not be leased exclusively.
+	ExitCodeDeviceLease = 7
+)
+
+// Error is an error returned by the runtime together with the exit code that
+// should be used to report it.
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps the specified error with an exit code. A nil error is returned
+// unchanged.
+func newError(code int, format string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: fmt.Errorf(format+": %v", err)}
+}
+
+// ExitCode returns the exit code for the specified error.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var rerr *Error
+	if errors.As(err, &rerr) {
+		return rerr.Code
+	}
+	return ExitCodeError
+}
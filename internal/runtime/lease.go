@@ -0,0 +1,84 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/lease"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+// leaseError is returned if the devices of a container could not be leased. It
+// is not subject to the on-error policy.
+type leaseError struct {
+	err error
+}
+
+func (e *leaseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *leaseError) Unwrap() error {
+	return e.err
+}
+
+// newLeaseManager creates the manager for the device leases in the configured
+// lease directory.
+func newLeaseManager(cfg *config.Config) *lease.Manager {
+	return lease.New(cfg.Exclusive.LeaseDir, state.New(cfg.StateDir))
+}
+
+// acquireLeases takes an exclusive lease on each device recorded for the
+// container. Depending on the conflict policy, a device leased by another
+// container fails the request or is waited for. Since leases are keyed by UUID,
+// devices without a UUID and CDI devices cannot be leased and fail the request.
+func acquireLeases(cfg *config.Config, c *state.Container) error {
+	if len(c.CDIDevices) > 0 {
+		return fmt.Errorf("CDI devices %v cannot be leased", c.CDIDevices)
+	}
+	var uuids []string
+	for _, d := range c.Devices {
+		if d.UUID == "" {
+			return fmt.Errorf("device %v cannot be leased without a UUID", d.Index)
+		}
+		uuids = append(uuids, d.UUID)
+	}
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	manager := newLeaseManager(cfg)
+	var err error
+	switch cfg.Exclusive.OnConflict {
+	case config.ExclusiveOnConflictWait:
+		timeout := time.Duration(cfg.Exclusive.Timeout) * time.Second
+		err = manager.AcquireWithTimeout(c.ID, c.Bundle, uuids, timeout)
+	default:
+		err = manager.Acquire(c.ID, c.Bundle, uuids)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lease devices %v: %v", uuids, err)
+	}
+	log.Infof("Leased devices %v to container %v", uuids, c.ID)
+	return nil
+}
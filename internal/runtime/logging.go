@@ -0,0 +1,77 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
+	"gitee.com/deep-spark/ix-container-runtime/internal/logger"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+)
+
+const (
+	// logLevelEnvvar is the container environment variable that overrides the log
+	// level of the runtime for that container.
+	logLevelEnvvar = "IX_RUNTIME_LOG_LEVEL"
+	// logLevelAnnotation is the OCI annotation that overrides the log level of the
+	// runtime for the container. It takes precedence over logLevelEnvvar.
+	logLevelAnnotation = "iluvatar.com/runtime-log-level"
+)
+
+// addLogContext adds the container ID, bundle and subcommand from the arguments
+// to all subsequent log entries.
+func addLogContext(argv []string) {
+	fields := log.Fields{
+		"container-id": oci.GetContainerID(argv),
+		"subcommand":   oci.GetSubcommand(argv),
+	}
+	if oci.HasBundleSubcommand(argv) {
+		fields["bundle"] = bundleDir(argv)
+	}
+	log.AddHook(logger.NewContextHook(fields))
+}
+
+// applyContainerLogLevel applies the log level requested by the container, if any.
+// Since the level applies to the host runtime, the environment variable, which
+// is under the control of the image and its user, is only honored for
+// privileged containers.
+func applyContainerLogLevel(spec *specs.Spec) {
+	value := spec.Annotations[logLevelAnnotation]
+	if value == "" && spec.Process != nil && image.IsPrivileged(spec) {
+		for _, env := range spec.Process.Env {
+			if key, v, _ := strings.Cut(env, "="); key == logLevelEnvvar {
+				value = v
+			}
+		}
+	}
+	if value == "" {
+		return
+	}
+
+	level, err := log.ParseLevel(value)
+	if err != nil {
+		log.Warnf("Ignoring invalid log level %q requested by container: %v", value, err)
+		return
+	}
+	log.SetLevel(level)
+	log.Infof("Using log level %v requested by container", level)
+}
@@ -0,0 +1,141 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
+	"gitee.com/deep-spark/ix-container-runtime/internal/modifier"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+)
+
+const (
+	executableName = "ix-container-runtime"
+)
+
+// ModeFromExecutable returns the mode encoded in the name of the executable.
+// An executable named ix-container-runtime.cdi selects the cdi mode, while
+// ix-container-runtime itself does not select a mode.
+func ModeFromExecutable(argv0 string) string {
+	name := filepath.Base(argv0)
+	if !strings.HasPrefix(name, executableName+".") {
+		return ""
+	}
+	return strings.TrimPrefix(name, executableName+".")
+}
+
+// resolveMode returns the effective mode for the runtime. The mode override
+// takes precedence over the mode from the config and auto is resolved to
+// either cdi or legacy.
+func resolveMode(cfg *config.Config, modeOverride string) (string, error) {
+	mode := cfg.Mode
+	if modeOverride != "" {
+		mode = modeOverride
+	}
+	if !config.IsValidMode(mode) {
+		return "", fmt.Errorf("invalid runtime mode %q", mode)
+	}
+
+	if mode != config.ModeAuto {
+		return mode, nil
+	}
+	if modifier.HasCDIDevices(cfg.CDI.SpecDirs, cfg.CDI.DefaultKind) {
+		return config.ModeCDI, nil
+	}
+	return config.ModeLegacy, nil
+}
+
+// NewSpecModifier creates the modifier that is applied to the OCI spec of the
+// container described by the specified spec. The mode override, if not empty,
+// takes precedence over the mode from the config. This is shared by the runtime
+// shim and the OCI hook so that both apply the same modifications.
+func NewSpecModifier(cfg *config.Config, modeOverride string, spec *specs.Spec) (oci.SpecModifier, error) {
+	return newSpecModifierForSpec(cfg, modeOverride, spec, false)
+}
+
+// NewQueryOnlySpecModifier creates the same modifier as NewSpecModifier, except
+// that SDK caches are only queried and never prepared. It is used to simulate the
+// modifications without side effects on the host.
+func NewQueryOnlySpecModifier(cfg *config.Config, modeOverride string, spec *specs.Spec) (oci.SpecModifier, error) {
+	return newSpecModifierForSpec(cfg, modeOverride, spec, true)
+}
+
+func newSpecModifierForSpec(cfg *config.Config, modeOverride string, spec *specs.Spec, queryOnly bool) (oci.SpecModifier, error) {
+	image, err := image.NewCUDAImageFromSpec(spec, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := resolveMode(cfg, modeOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSpecModifier(mode, image, queryOnly)
+}
+
+// newSpecModifier creates the modifier that is applied to the OCI spec of a
+// container for the specified mode. If queryOnly is set, missing SDK caches are
+// not prepared.
+func newSpecModifier(mode string, image image.CUDA, queryOnly bool) (oci.SpecModifier, error) {
+	log.Infof("Using runtime mode %v", mode)
+
+	var modifiers []oci.SpecModifier
+	switch mode {
+	case config.ModeCDI:
+		cdiModifier, err := modifier.NewCDIModifier(image)
+		if err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, cdiModifier)
+	default:
+		graphicsModifier, err := modifier.NewGraphicsModifier(image)
+		if err != nil {
+			return nil, err
+		}
+		cdiModifier, err := modifier.NewCDIAnnotationModifier(image)
+		if err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, graphicsModifier, cdiModifier)
+	}
+	newSdkModifier := modifier.NewSdkModifier
+	if queryOnly {
+		newSdkModifier = modifier.NewQueryOnlySdkModifier
+	}
+	sdkModifier, err := newSdkModifier(image)
+	if err != nil {
+		return nil, err
+	}
+	// The driver modifier is applied after the SDK modifier so that the host
+	// driver libraries take precedence over the SDK in LD_LIBRARY_PATH.
+	driverModifier, err := modifier.NewDriverModifier(image)
+	if err != nil {
+		return nil, err
+	}
+	modifiers = append(modifiers, sdkModifier, driverModifier)
+
+	return modifier.Merge(modifiers...), nil
+}
@@ -0,0 +1,117 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/go-ixml/pkg/ixml"
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
+	"gitee.com/deep-spark/ix-container-runtime/internal/requirements"
+)
+
+const (
+	// requirementDriver is the property for the version of the driver.
+	requirementDriver = "driver"
+	// requirementCoreX is the property for the version of the CoreX driver. It
+	// is the same version as the driver property.
+	requirementCoreX = "corex"
+	// requirementCUDA is the property for the CUDA version supported by the
+	// CoreX driver.
+	requirementCUDA = "cuda"
+)
+
+// CheckRequirements checks the requirements declared by the container described
+// by the spec (e.g. IX_REQUIRE_COREX=corex>=4.1) against the driver of the
+// host. The versions are only queried if the container declares requirements
+// and the check is not disabled through IX_DISABLE_REQUIRE.
+func CheckRequirements(cfg *config.Config, spec *specs.Spec) error {
+	cudaImage, err := image.NewCUDAImageFromSpec(spec, cfg)
+	if err != nil {
+		return err
+	}
+
+	declared := cudaImage.Requirements()
+	if len(declared) == 0 {
+		return nil
+	}
+	if cudaImage.RequirementsDisabled() {
+		log.Infof("Not checking requirements %q: disabled by the container", declared)
+		return nil
+	}
+
+	driverVersion, cudaVersion := driverVersions(cfg.LibraryPath)
+	log.Infof("Checking requirements %q against CoreX driver %q and CUDA %q", declared, driverVersion, cudaVersion)
+
+	r := requirements.New()
+	r.AddVersionProperty(requirementDriver, driverVersion)
+	r.AddVersionProperty(requirementCoreX, driverVersion)
+	r.AddVersionProperty(requirementCUDA, cudaVersion)
+	r.AddConstraint(declared...)
+	return r.Assert()
+}
+
+// driverVersions returns the version of the CoreX driver and the CUDA version
+// it supports as reported by ixml. Versions that cannot be determined are
+// returned empty.
+func driverVersions(libraryPath string) (string, string) {
+	var ret ixml.Return
+	if libraryPath != "" {
+		ret = ixml.AbsInit(libraryPath)
+	} else {
+		ret = ixml.Init()
+	}
+	if ret != ixml.SUCCESS {
+		log.Warnf("Unable to initialize IXML from %q: %v", libraryPath, ret)
+		return "", ""
+	}
+	defer func() {
+		if ret := ixml.Shutdown(); ret != ixml.SUCCESS {
+			log.Warnf("Failed to shutdown IXML: %v", ret)
+		}
+	}()
+
+	driverVersion, ret := ixml.SystemGetDriverVersion()
+	if ret != ixml.SUCCESS {
+		log.Warnf("Unable to get driver version: %v", ret)
+		driverVersion = ""
+	}
+
+	cudaVersion, ret := ixml.SystemGetCudaDriverVersion()
+	if ret != ixml.SUCCESS {
+		log.Warnf("Unable to get CUDA driver version: %v", ret)
+		cudaVersion = ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(driverVersion, "\x00")), cudaDriverVersion(cudaVersion)
+}
+
+// cudaDriverVersion converts a CUDA driver version as reported by ixml (e.g.
+// 10020) to the major.minor form (e.g. 10.2).
+func cudaDriverVersion(version string) string {
+	v, err := strconv.Atoi(version)
+	if err != nil || v <= 0 {
+		return ""
+	}
+	return strconv.Itoa(v/1000) + "." + strconv.Itoa(v%1000/10)
+}
@@ -18,54 +18,104 @@
 package runtime
 
 import (
-	"os"
+	"errors"
 
 	log "github.com/sirupsen/logrus"
 
 	"gitee.com/deep-spark/ix-container-runtime/internal/config"
-	"gitee.com/deep-spark/ix-container-runtime/internal/config/image"
-	"gitee.com/deep-spark/ix-container-runtime/internal/modifier"
 	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
 )
 
-var (
-	defaultRuntime = []string{"docker-runc", "runc", "crun"}
-)
+// configFlag is the runtime argument that specifies the path of the config file.
+// It is removed from the arguments passed to the low-level runtime.
+const configFlag = "config"
 
 func (r rt) Run(argv []string) (rerr error) {
-	cfg, err := config.LoadConfig()
+	configPath, argv, err := oci.RemoveGlobalFlag(argv, configFlag)
 	if err != nil {
-		return err
+		return newError(ExitCodeConfig, "failed to parse arguments", err)
 	}
 
-	lowLevelRuntime, err := oci.NewLowLevelRuntime(defaultRuntime)
+	cfg, err := config.LoadConfigWithPath(configPath)
+	if err != nil {
+		return newError(ExitCodeConfig, "failed to load config", err)
+	}
 
-	if !oci.HasCreateSubcommand(argv) {
-		return lowLevelRuntime.Exec(argv)
-	} else {
-		ociSpec, err := oci.NewSpec(argv)
-		if err != nil {
-			log.Printf("error: NewSpec\n")
-			os.Exit(0)
-		}
+	addLogContext(argv)
+	containerID := oci.GetContainerID(argv)
 
-		rawSpec, err := ociSpec.Load()
+	if !oci.HasBundleSubcommand(argv) {
+		lowLevelRuntime, err := newLowLevelRuntimeForContainer(cfg, containerID)
 		if err != nil {
-			log.Printf("error: load spec\n")
-			os.Exit(0)
+			return newError(ExitCodeLowLevelRuntime, "failed to create low-level runtime", err)
 		}
+		// The low-level runtime is selected before the cleanup, which forgets
+		// the selection of a deleted container.
+		cleanupContainer(cfg, lowLevelRuntime, argv, containerID)
+		return newError(ExitCodeLowLevelRuntime, "failed to exec low-level runtime", lowLevelRuntime.Exec(argv))
+	}
 
-		image, err := image.NewCUDAImageFromSpec(rawSpec, cfg)
-		if err != nil {
-			log.Printf("new cuda image from spec\n")
-			os.Exit(0)
-		}
+	ociSpec, err := oci.NewSpec(argv)
+	if err != nil {
+		return r.handleError(cfg, argv, nil, newError(ExitCodeSpec, "failed to construct OCI spec", err))
+	}
+
+	rawSpec, err := ociSpec.Load()
+	if err != nil {
+		return r.handleError(cfg, argv, nil, newError(ExitCodeSpec, "failed to load OCI spec", err))
+	}
+	applyContainerLogLevel(rawSpec)
 
-		gpuModifier := modifier.NewGraphicsModifier(image)
-		sdkModifier := modifier.NewSdkModifier(image)
-		mergeModifier := modifier.Merge(gpuModifier, sdkModifier)
-		r := oci.NewModifyingRuntimeWrapper(lowLevelRuntime, ociSpec, mergeModifier)
+	lowLevelRuntime, err := newLowLevelRuntimeForSpec(cfg, rawSpec, containerID)
+	if err != nil {
+		return newError(ExitCodeLowLevelRuntime, "failed to create low-level runtime", err)
+	}
 
-		return r.Exec(argv)
+	// Unmet requirements are not a failure to prepare the container and are
+	// therefore not subject to the on-error policy.
+	err = CheckRequirements(cfg, rawSpec)
+	if err != nil {
+		return newError(ExitCodeRequirements, "container requirements not met", err)
+	}
+
+	specModifier, err := NewSpecModifier(cfg, r.modeOverride, rawSpec)
+	if err != nil {
+		return r.handleError(cfg, argv, lowLevelRuntime, newError(ExitCodeModifier, "failed to construct OCI spec modifier", err))
+	}
+
+	runtime := oci.NewModifyingRuntimeWrapper(
+		withStateRecording(lowLevelRuntime, cfg, argv, specModifier),
+		ociSpec,
+		specModifier,
+	)
+	err = runtime.Exec(argv)
+
+	var leaseErr *leaseError
+	if errors.As(err, &leaseErr) {
+		return newError(ExitCodeDeviceLease, "device lease not granted", err)
+	}
+	var modificationError *oci.ModificationError
+	if errors.As(err, &modificationError) {
+		return r.handleError(cfg, argv, lowLevelRuntime, newError(ExitCodeModifier, "failed to modify OCI spec", err))
+	}
+	return newError(ExitCodeLowLevelRuntime, "failed to exec low-level runtime", err)
+}
+
+// handleError applies the on-error policy from the config to an error encountered
+// while preparing a container. With the passthrough policy the low-level runtime
+// is executed with the unmodified spec; otherwise the error is returned.
+func (r rt) handleError(cfg *config.Config, argv []string, lowLevelRuntime oci.Runtime, err error) error {
+	if cfg.OnError != config.OnErrorPassthrough {
+		return err
+	}
+
+	log.Warnf("Ignoring error due to on-error=%v policy: %v", config.OnErrorPassthrough, err)
+	if lowLevelRuntime == nil {
+		var rerr error
+		lowLevelRuntime, rerr = newDefaultLowLevelRuntime(cfg)
+		if rerr != nil {
+			return newError(ExitCodeLowLevelRuntime, "failed to create low-level runtime", rerr)
+		}
 	}
+	return newError(ExitCodeLowLevelRuntime, "failed to exec low-level runtime", lowLevelRuntime.Exec(argv))
 }
@@ -0,0 +1,144 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+)
+
+const (
+	// lowLevelRuntimeAnnotation is the OCI annotation used by a container to request
+	// one of the allowed low-level runtimes.
+	lowLevelRuntimeAnnotation = "iluvatar.com/low-level-runtime"
+
+	// runtimeSelectionDir stores the low-level runtime requested by each container so
+	// that subcommands without a bundle (start, kill, delete, ...) use the same runtime.
+	runtimeSelectionDir = "/run/iluvatar/runtimes"
+)
+
+// newDefaultLowLevelRuntime creates the low-level runtime defined in the config.
+func newDefaultLowLevelRuntime(cfg *config.Config) (oci.Runtime, error) {
+	if cfg.LowLevelRuntime.Path != "" {
+		log.Infof("Using low-level runtime %v", cfg.LowLevelRuntime.Path)
+		return oci.NewRuntimeForPath(cfg.LowLevelRuntime.Path, cfg.LowLevelRuntime.Args...)
+	}
+	return oci.NewLowLevelRuntime(cfg.LowLevelRuntime.Runtimes, cfg.LowLevelRuntime.Args...)
+}
+
+// newAllowedLowLevelRuntime creates the low-level runtime registered under the
+// specified name in the allowlist.
+func newAllowedLowLevelRuntime(cfg *config.Config, name string) (oci.Runtime, error) {
+	executable, ok := cfg.LowLevelRuntime.AllowedRuntimes[name]
+	if !ok || executable == "" {
+		return nil, fmt.Errorf("low-level runtime %q is not allowed", name)
+	}
+	return oci.NewLowLevelRuntime([]string{executable})
+}
+
+// newLowLevelRuntimeForSpec creates the low-level runtime for a container being
+// created from the specified spec. The selection is recorded for the container ID
+// so that later invocations for the same container use the same runtime.
+func newLowLevelRuntimeForSpec(cfg *config.Config, spec *specs.Spec, containerID string) (oci.Runtime, error) {
+	name := spec.Annotations[lowLevelRuntimeAnnotation]
+	if name == "" {
+		if err := removeRuntimeSelection(containerID); err != nil {
+			log.Warnf("Failed to remove runtime selection for %v: %v", containerID, err)
+		}
+		return newDefaultLowLevelRuntime(cfg)
+	}
+
+	log.Infof("Container %v requested low-level runtime %q", containerID, name)
+	runtime, err := newAllowedLowLevelRuntime(cfg, name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v annotation: %v", lowLevelRuntimeAnnotation, err)
+	}
+
+	if err := writeRuntimeSelection(containerID, name); err != nil {
+		return nil, fmt.Errorf("failed to record runtime selection: %v", err)
+	}
+	return runtime, nil
+}
+
+// newLowLevelRuntimeForContainer creates the low-level runtime previously selected
+// for the specified container, falling back to the default low-level runtime.
+func newLowLevelRuntimeForContainer(cfg *config.Config, containerID string) (oci.Runtime, error) {
+	name, err := readRuntimeSelection(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runtime selection: %v", err)
+	}
+	if name == "" {
+		return newDefaultLowLevelRuntime(cfg)
+	}
+	return newAllowedLowLevelRuntime(cfg, name)
+}
+
+// runtimeSelectionPath returns the path of the selection record for the container ID.
+// An empty path is returned if the ID cannot be used as a file name.
+func runtimeSelectionPath(containerID string) string {
+	if containerID == "" || containerID == "." || containerID == ".." || strings.ContainsRune(containerID, os.PathSeparator) {
+		return ""
+	}
+	return filepath.Join(runtimeSelectionDir, containerID)
+}
+
+func writeRuntimeSelection(containerID string, name string) error {
+	path := runtimeSelectionPath(containerID)
+	if path == "" {
+		return fmt.Errorf("invalid container ID %q", containerID)
+	}
+	if err := os.MkdirAll(runtimeSelectionDir, 0700); err != nil {
+		return fmt.Errorf("unable to create directory %v: %v", runtimeSelectionDir, err)
+	}
+	return os.WriteFile(path, []byte(name), 0600)
+}
+
+func readRuntimeSelection(containerID string) (string, error) {
+	path := runtimeSelectionPath(containerID)
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func removeRuntimeSelection(containerID string) error {
+	path := runtimeSelectionPath(containerID)
+	if path == "" {
+		return nil
+	}
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
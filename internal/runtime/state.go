@@ -0,0 +1,124 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/config"
+	"gitee.com/deep-spark/ix-container-runtime/internal/modifier"
+	"gitee.com/deep-spark/ix-container-runtime/internal/oci"
+	"gitee.com/deep-spark/ix-container-runtime/internal/state"
+)
+
+// stateRecordingRuntime records the resources injected into a container once
+// the modified spec has been written and before the low-level runtime is
+// executed.
+type stateRecordingRuntime struct {
+	oci.Runtime
+	cfg         *config.Config
+	containerID string
+	bundle      string
+	modifier    oci.SpecModifier
+}
+
+// withStateRecording wraps the low-level runtime so that the state of the
+// container is recorded before it is executed.
+func withStateRecording(runtime oci.Runtime, cfg *config.Config, argv []string, specModifier oci.SpecModifier) oci.Runtime {
+	if specModifier == nil {
+		return runtime
+	}
+	return &stateRecordingRuntime{
+		Runtime:     runtime,
+		cfg:         cfg,
+		containerID: oci.GetContainerID(argv),
+		bundle:      bundleDir(argv),
+		modifier:    specModifier,
+	}
+}
+
+// Exec records the state of the container and executes the wrapped runtime.
+// Failing to record the state does not prevent the container from being created
+// unless exclusive device leases are enabled, since the leases rely on the
+// record to detect that their owner no longer exists.
+func (r *stateRecordingRuntime) Exec(args []string) error {
+	c, err := r.record()
+	if err != nil {
+		if r.cfg.Exclusive.Enabled {
+			return &leaseError{fmt.Errorf("failed to record state of container %v: %v", r.containerID, err)}
+		}
+		log.Warnf("Failed to record state of container %v: %v", r.containerID, err)
+	}
+
+	if r.cfg.Exclusive.Enabled && c != nil {
+		if err := acquireLeases(r.cfg, c); err != nil {
+			if rerr := state.New(r.cfg.StateDir).Remove(c.ID); rerr != nil {
+				log.Warnf("Failed to remove state of container %v: %v", c.ID, rerr)
+			}
+			return &leaseError{err}
+		}
+	}
+	return r.Runtime.Exec(args)
+}
+
+// record records the state of the container and returns the record. A nil
+// record is returned if no resources were injected.
+func (r *stateRecordingRuntime) record() (*state.Container, error) {
+	store := state.New(r.cfg.StateDir)
+	if removed, err := store.RemoveStale(); err != nil {
+		log.Warnf("Failed to remove stale container records: %v", err)
+	} else if len(removed) > 0 {
+		log.Infof("Removed stale container records %v", removed)
+	}
+
+	c := &state.Container{
+		ID:      r.containerID,
+		Bundle:  r.bundle,
+		Created: time.Now().UTC(),
+	}
+	if recorder, ok := r.modifier.(modifier.Recorder); ok {
+		recorder.Record(c)
+	}
+	if c.IsEmpty() {
+		return nil, store.Remove(c.ID)
+	}
+	return c, store.Save(c)
+}
+
+// bundleDir returns the absolute path of the bundle specified in the arguments.
+// The bundle defaults to the current directory.
+func bundleDir(argv []string) string {
+	bundle, err := oci.GetBundleDir(argv)
+	if err != nil {
+		return ""
+	}
+	if bundle == "" {
+		bundle, _ = os.Getwd()
+		return bundle
+	}
+	abs, err := filepath.Abs(bundle)
+	if err != nil {
+		return bundle
+	}
+	return abs
+}
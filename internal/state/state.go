@@ -0,0 +1,201 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/atomicfile"
+)
+
+// Container is the record of the resources the runtime injected into a container.
+type Container struct {
+	ID     string `json:"id"`
+	Bundle string `json:"bundle"`
+	// Devices are the devices injected by the runtime.
+	Devices []Device `json:"devices,omitempty"`
+	// CDIDevices are the fully-qualified names of the injected CDI devices.
+	CDIDevices []string `json:"cdiDevices,omitempty"`
+	// SdkImage is the SDK image requested by the container.
+	SdkImage string `json:"sdkImage,omitempty"`
+	// SdkDestination is the host path of the SDK cache mounted into the container.
+	SdkDestination string    `json:"sdkDestination,omitempty"`
+	Created        time.Time `json:"created"`
+}
+
+// Device is a device injected into a container.
+type Device struct {
+	Index uint   `json:"index"`
+	UUID  string `json:"uuid,omitempty"`
+	Path  string `json:"path"`
+	// ContainerPath is the path of the device node in the container if it
+	// differs from the host path.
+	ContainerPath string `json:"containerPath,omitempty"`
+}
+
+// IsEmpty returns whether no resources are recorded for the container.
+func (c *Container) IsEmpty() bool {
+	return len(c.Devices) == 0 && len(c.CDIDevices) == 0 && c.SdkImage == ""
+}
+
+// IsStale returns whether the bundle of the container no longer exists.
+func (c *Container) IsStale() bool {
+	if c.Bundle == "" {
+		return false
+	}
+	_, err := os.Stat(c.Bundle)
+	return os.IsNotExist(err)
+}
+
+// Store stores the container records as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// New creates a store for the records in the specified directory.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the path of the record for the container ID.
+func (s *Store) path(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsRune(id, os.PathSeparator) {
+		return "", fmt.Errorf("invalid container ID %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Save writes the record for the container, replacing an existing record.
+func (s *Store) Save(c *Container) error {
+	path, err := s.path(c.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("unable to create directory %v: %v", s.dir, err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode state of container %v: %v", c.ID, err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state of container %v: %v", c.ID, err)
+	}
+	return nil
+}
+
+// Get returns the record for the container ID. A nil record is returned if
+// none exists.
+func (s *Store) Get(id string) (*Container, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return load(path)
+}
+
+// List returns the records of all containers ordered by creation time. Records
+// that cannot be read are skipped with a warning.
+func (s *Store) List() ([]*Container, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory %v: %v", s.dir, err)
+	}
+
+	var containers []*Container
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		c, err := load(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			log.Warnf("Ignoring container record: %v", err)
+			continue
+		}
+		if c != nil {
+			containers = append(containers, c)
+		}
+	}
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].Created.Before(containers[j].Created)
+	})
+	return containers, nil
+}
+
+// Remove removes the record for the container ID. Removing a record that does
+// not exist is not an error.
+func (s *Store) Remove(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state of container %v: %v", id, err)
+	}
+	return nil
+}
+
+// RemoveStale removes the records of containers whose bundle no longer exists
+// and returns their IDs.
+func (s *Store) RemoveStale() ([]string, error) {
+	containers, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, c := range containers {
+		if !c.IsStale() {
+			continue
+		}
+		if err := s.Remove(c.ID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, c.ID)
+	}
+	return removed, nil
+}
+
+func load(path string) (*Container, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %v: %v", path, err)
+	}
+	var c Container
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode state file %v: %v", path, err)
+	}
+	return &c, nil
+}
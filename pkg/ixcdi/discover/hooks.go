@@ -0,0 +1,201 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// HookCreateContainer is the OCI lifecycle of the hooks run by ix-ctk. These
+	// hooks run in the mount namespace of the container before its root
+	// filesystem is pivoted.
+	HookCreateContainer = "createContainer"
+
+	// UpdateLDCacheHook is the ix-ctk hook subcommand that updates the linker
+	// cache of the container.
+	UpdateLDCacheHook = "update-ldcache"
+
+	// CreateSymlinksHook is the ix-ctk hook subcommand that creates symlinks in
+	// the container.
+	CreateSymlinksHook = "create-symlinks"
+)
+
+// ldconfig is a discoverer for the hook that updates the linker cache of the
+// container for the libraries mounted by another discoverer.
+type ldconfig struct {
+	None
+	mounts  Discover
+	ctkPath string
+}
+
+var _ Discover = (*ldconfig)(nil)
+
+// NewLDCacheUpdateHook creates a discoverer for the hook that adds the
+// directories of the libraries mounted by the specified discoverer to the linker
+// cache of the container. No hook is returned if no libraries are mounted.
+func NewLDCacheUpdateHook(mounts Discover, ctkPath string) Discover {
+	return &ldconfig{
+		mounts:  mounts,
+		ctkPath: ctkPath,
+	}
+}
+
+// Hooks returns the hook that updates the linker cache of the container.
+func (d ldconfig) Hooks() ([]Hook, error) {
+	mounts, err := d.mounts.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	folders := libraryFolders(mounts)
+	if len(folders) == 0 {
+		return nil, nil
+	}
+	return []Hook{CreateLDCacheUpdateHook(d.ctkPath, folders...)}, nil
+}
+
+// CreateLDCacheUpdateHook creates the hook that adds the specified folders to
+// the linker cache of the container.
+func CreateLDCacheUpdateHook(ctkPath string, folders ...string) Hook {
+	var args []string
+	for _, f := range folders {
+		args = append(args, "--folder", f)
+	}
+	return CreateIXCTKHook(ctkPath, UpdateLDCacheHook, args...)
+}
+
+// symlinks is a discoverer for the hook that creates the unversioned links for
+// the libraries mounted by another discoverer.
+type symlinks struct {
+	None
+	mounts  Discover
+	ctkPath string
+}
+
+var _ Discover = (*symlinks)(nil)
+
+// NewCreateSymlinksHook creates a discoverer for the hook that creates the
+// unversioned links (e.g. libixml.so) for the versioned libraries mounted by the
+// specified discoverer. No hook is returned if no links are required.
+func NewCreateSymlinksHook(mounts Discover, ctkPath string) Discover {
+	return &symlinks{
+		mounts:  mounts,
+		ctkPath: ctkPath,
+	}
+}
+
+// Hooks returns the hook that creates the library links in the container.
+func (d symlinks) Hooks() ([]Hook, error) {
+	mounts, err := d.mounts.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	links := LibrarySymlinks(mounts)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return []Hook{CreateCreateSymlinksHook(d.ctkPath, links...)}, nil
+}
+
+// CreateCreateSymlinksHook creates the hook that creates the specified links in
+// the container. Each link is specified as target::link.
+func CreateCreateSymlinksHook(ctkPath string, links ...string) Hook {
+	var args []string
+	for _, l := range links {
+		args = append(args, "--link", l)
+	}
+	return CreateIXCTKHook(ctkPath, CreateSymlinksHook, args...)
+}
+
+// LibrarySymlinks returns the links, as target::link, from the unversioned name
+// of each mounted versioned library to the library. The library with the
+// shortest name (e.g. libixml.so.1 rather than libixml.so.1.2.0) is used as
+// the target. No link is returned for libraries whose unversioned name is
+// mounted itself.
+func LibrarySymlinks(mounts []Mount) []string {
+	mounted := make(map[string]bool)
+	for _, m := range mounts {
+		mounted[m.Path] = true
+	}
+
+	targets := make(map[string]string)
+	for _, m := range mounts {
+		name := filepath.Base(m.Path)
+		i := strings.Index(name, ".so.")
+		if i < 0 {
+			continue
+		}
+		link := filepath.Join(filepath.Dir(m.Path), name[:i+len(".so")])
+		if mounted[link] {
+			continue
+		}
+		if current, ok := targets[link]; ok && !shorterName(name, current) {
+			continue
+		}
+		targets[link] = name
+	}
+
+	var links []string
+	for link, target := range targets {
+		links = append(links, target+"::"+link)
+	}
+	sort.Strings(links)
+	return links
+}
+
+// shorterName checks whether the name a is shorter than b. Names of the same
+// length are compared lexically.
+func shorterName(a string, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// CreateIXCTKHook creates a hook that runs the specified ix-ctk hook subcommand.
+func CreateIXCTKHook(ctkPath string, hookName string, args ...string) Hook {
+	return Hook{
+		Lifecycle: HookCreateContainer,
+		Path:      ctkPath,
+		Args:      append([]string{filepath.Base(ctkPath), "hook", hookName}, args...),
+	}
+}
+
+// libraryFolders returns the sorted container directories of the mounted
+// shared libraries.
+func libraryFolders(mounts []Mount) []string {
+	seen := make(map[string]bool)
+	var folders []string
+	for _, m := range mounts {
+		if !strings.Contains(filepath.Base(m.Path), ".so") {
+			continue
+		}
+		dir := filepath.Dir(m.Path)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		folders = append(folders, dir)
+	}
+	sort.Strings(folders)
+	return folders
+}
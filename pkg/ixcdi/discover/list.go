@@ -0,0 +1,78 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package discover
+
+import "fmt"
+
+// list is a discoverer that returns the combined devices, mounts and hooks of
+// a list of discoverers.
+type list []Discover
+
+var _ Discover = (list)(nil)
+
+// Merge creates a discoverer that returns the devices, mounts and hooks of the
+// specified discoverers in order. Nil discoverers are ignored.
+func Merge(discoverers ...Discover) Discover {
+	var l list
+	for _, d := range discoverers {
+		if d == nil {
+			continue
+		}
+		l = append(l, d)
+	}
+	return l
+}
+
+// Devices returns the devices of all discoverers in the list.
+func (l list) Devices() ([]Device, error) {
+	var devices []Device
+	for i, d := range l {
+		discovered, err := d.Devices()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering devices for discoverer %v: %v", i, err)
+		}
+		devices = append(devices, discovered...)
+	}
+	return devices, nil
+}
+
+// Mounts returns the mounts of all discoverers in the list.
+func (l list) Mounts() ([]Mount, error) {
+	var mounts []Mount
+	for i, d := range l {
+		discovered, err := d.Mounts()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering mounts for discoverer %v: %v", i, err)
+		}
+		mounts = append(mounts, discovered...)
+	}
+	return mounts, nil
+}
+
+// Hooks returns the hooks of all discoverers in the list.
+func (l list) Hooks() ([]Hook, error) {
+	var hooks []Hook
+	for i, d := range l {
+		discovered, err := d.Hooks()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering hooks for discoverer %v: %v", i, err)
+		}
+		hooks = append(hooks, discovered...)
+	}
+	return hooks, nil
+}
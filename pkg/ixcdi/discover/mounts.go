@@ -18,9 +18,13 @@
 package discover
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
+	log "github.com/sirupsen/logrus"
+
 	"gitee.com/deep-spark/ix-container-runtime/internal/lookup"
 )
 
@@ -31,6 +35,7 @@ type mounts struct {
 	None
 	lookup   lookup.Locator
 	root     string
+	dir      string
 	required []string
 	sync.Mutex
 	cache []Mount
@@ -43,6 +48,15 @@ func NewMounts(lookup lookup.Locator, root string, required []string) Discover {
 	return newMounts(lookup, root, required)
 }
 
+// NewMountsAt creates a discoverer for the required mounts using the specified
+// locator. The located files are mounted into the specified container directory
+// instead of at their host path.
+func NewMountsAt(lookup lookup.Locator, dir string, required []string) Discover {
+	m := newMounts(lookup, "", required)
+	m.dir = dir
+	return m
+}
+
 // newMounts creates a discoverer for the required mounts using the specified locator.
 func newMounts(lookup lookup.Locator, root string, required []string) *mounts {
 	return &mounts{
@@ -52,6 +66,58 @@ func newMounts(lookup lookup.Locator, root string, required []string) *mounts {
 	}
 }
 
+// Mounts returns a read-only bind mount for each file located for the required
+// entries. A file is only mounted once, even if it matches several entries.
 func (d *mounts) Mounts() ([]Mount, error) {
-	return nil, nil
+	if d.lookup == nil {
+		return nil, fmt.Errorf("no lookup defined")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.cache != nil {
+		return d.cache, nil
+	}
+
+	seen := make(map[string]bool)
+	var mounts []Mount
+	for _, candidate := range d.required {
+		located, err := d.lookup.Locate(candidate)
+		if err != nil {
+			log.Warnf("Could not locate %v: %v", candidate, err)
+			continue
+		}
+		if len(located) == 0 {
+			log.Warnf("Missing %v", candidate)
+			continue
+		}
+
+		for _, p := range located {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+
+			path := p
+			if d.dir != "" {
+				path = filepath.Join(d.dir, filepath.Base(p))
+			} else if d.root != "/" {
+				path = strings.TrimPrefix(p, d.root)
+			}
+			mounts = append(mounts, Mount{
+				HostPath: p,
+				Path:     path,
+				Options: []string{
+					"ro",
+					"nosuid",
+					"nodev",
+					"bind",
+				},
+			})
+		}
+	}
+	d.cache = mounts
+
+	return d.cache, nil
 }
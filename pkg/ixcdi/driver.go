@@ -0,0 +1,84 @@
+/**
+# Copyright (c) 2024, Shanghai Iluvatar CoreX Semiconductor Co., Ltd.
+# All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License"); you may
+# not use this file except in compliance with the License. You may obtain
+# a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+**/
+
+package ixcdi
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gitee.com/deep-spark/ix-container-runtime/internal/lookup"
+	"gitee.com/deep-spark/ix-container-runtime/pkg/ixcdi/discover"
+	"gitee.com/deep-spark/ix-container-runtime/pkg/ixcdi/edits"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+const (
+	// DriverRoot is the container directory under which the host driver files
+	// are mounted. A dedicated directory is used so that the mounts do not
+	// conflict with a CoreX SDK in the image or mounted at /usr/local/corex.
+	DriverRoot = "/usr/local/iluvatar/driver"
+	// DriverLibraryDir is the container directory of the driver libraries.
+	DriverLibraryDir = DriverRoot + "/lib64"
+	// DriverExecutableDir is the container directory of the driver tools.
+	DriverExecutableDir = DriverRoot + "/bin"
+)
+
+// DefaultDriverLibraries are the host driver libraries required by CUDA
+// applications and the management tools.
+var DefaultDriverLibraries = []string{
+	"libcuda.so*",
+	"libixthunk.so*",
+	"libixml.so*",
+}
+
+// GetCommonEdits returns the container edits shared by all devices. These are
+// the mounts of the configured driver libraries into DriverLibraryDir and, if
+// the path of ix-ctk is set, the hooks that create the unversioned links to the
+// libraries and add them to the linker cache.
+func (l *ixmllib) GetCommonEdits() (*cdi.ContainerEdits, error) {
+	if len(l.driverLibraries) == 0 {
+		return edits.NewContainerEdits(), nil
+	}
+
+	var searchPaths []string
+	if l.libraryPath != "" {
+		searchPaths = append(searchPaths, filepath.Dir(l.libraryPath))
+	}
+	searchPaths = append(searchPaths, lookup.DefaultLibrarySearchPaths...)
+
+	libraries := discover.NewMountsAt(
+		lookup.NewLibraryLocator("", searchPaths...),
+		DriverLibraryDir,
+		l.driverLibraries,
+	)
+
+	var d discover.Discover = libraries
+	if l.ctkPath != "" {
+		d = discover.Merge(
+			libraries,
+			discover.NewCreateSymlinksHook(libraries, l.ctkPath),
+			discover.NewLDCacheUpdateHook(libraries, l.ctkPath),
+		)
+	}
+
+	commonEdits, err := edits.FromDiscoverer(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create common container edits: %v", err)
+	}
+	return commonEdits, nil
+}
@@ -25,8 +25,10 @@ type wrapper struct {
 }
 
 type ixcdilib struct {
-	libraryPath  string
-	deviceNamers DeviceNamers
+	libraryPath     string
+	deviceNamers    DeviceNamers
+	ctkPath         string
+	driverLibraries []string
 
 	vendor string
 	class  string
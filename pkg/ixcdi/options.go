@@ -47,3 +47,17 @@ func WithLibraryPath(path string) Option {
 		o.libraryPath = path
 	}
 }
+
+// WithIXCTKPath sets the path of the ix-ctk executable used in the generated hooks
+func WithIXCTKPath(path string) Option {
+	return func(o *ixcdilib) {
+		o.ctkPath = path
+	}
+}
+
+// WithDriverLibraries sets the host driver libraries included in the common edits
+func WithDriverLibraries(libraries ...string) Option {
+	return func(o *ixcdilib) {
+		o.driverLibraries = libraries
+	}
+}